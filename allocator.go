@@ -0,0 +1,190 @@
+package cidrman
+
+import (
+	"fmt"
+	"net"
+	"sync"
+)
+
+// Family is an IP address family.
+type Family int
+
+// The IP address families.
+const (
+	IPv4 Family = 4
+	IPv6 Family = 6
+)
+
+func (f Family) String() string {
+	switch f {
+	case IPv4:
+		return "IPv4"
+	case IPv6:
+		return "IPv6"
+	}
+	return fmt.Sprintf("Family(%d)", int(f))
+}
+
+// Allocator hands out free subnets from one or more pools of IPv4 and IPv6 address space.
+// Allocated and free space are kept as coalesced address ranges, so released blocks
+// merge back with their free neighbours.
+// It is safe for concurrent use.
+type Allocator struct {
+	mu    sync.Mutex
+	pool4 cidrBlock4s
+	used4 cidrBlock4s
+	pool6 cidrBlock6s
+	used6 cidrBlock6s
+}
+
+// NewAllocator returns an Allocator handing out subnets of the pool CIDR blocks.
+func NewAllocator(pools []string) (*Allocator, error) {
+	networks, err := parseCIDRs(pools)
+	if err != nil {
+		return nil, err
+	}
+
+	block4s, block6s := ipNets(networks).toBlocks()
+	return &Allocator{pool4: coalesce4(block4s), pool6: coalesce6(block6s)}, nil
+}
+
+// bestFit4 returns the lowest address of the smallest free IPv4 CIDR block that fits the prefix.
+func bestFit4(free cidrBlock4s, prefix uint) (uint32, bool) {
+	var best uint32
+	var bestPrefix uint
+	found := false
+	for _, block := range free {
+		// Free ranges split into aligned CIDR blocks, in ascending address order.
+		splitRange4(0, 0, block.first, block.last, 0, func(addr uint32, p uint) {
+			if p <= prefix && (!found || p > bestPrefix) {
+				best, bestPrefix, found = addr, p, true
+			}
+		})
+	}
+
+	return best, found
+}
+
+// bestFit6 returns the lowest address of the smallest free IPv6 CIDR block that fits the prefix.
+func bestFit6(free cidrBlock6s, prefix uint) (uint128, bool) {
+	var best uint128
+	var bestPrefix uint
+	found := false
+	for _, block := range free {
+		// Free ranges split into aligned CIDR blocks, in ascending address order.
+		splitRange6(uint128{}, 0, block.first, block.last, 0, func(addr uint128, p uint) {
+			if p <= prefix && (!found || p > bestPrefix) {
+				best, bestPrefix, found = addr, p, true
+			}
+		})
+	}
+
+	return best, found
+}
+
+// Allocate allocates a free subnet of the prefix length from the pools of the address family.
+// The smallest free block that fits is used, and the lowest address within it, to keep
+// the remaining free space as unfragmented as possible. It returns ErrNoSpace when full.
+func (a *Allocator) Allocate(family Family, prefixLen int) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch family {
+	case IPv4:
+		if prefixLen < 0 || prefixLen > widthUInt32 {
+			return "", &PrefixLengthError{Prefix: prefixLen, Min: 0, Max: widthUInt32}
+		}
+		addr, ok := bestFit4(remove4(a.pool4.copy(), a.used4.copy()), uint(prefixLen))
+		if !ok {
+			return "", ErrNoSpace
+		}
+		a.used4 = coalesce4(append(a.used4, &cidrBlock4{first: addr, last: broadcast4(addr, uint(prefixLen))}))
+		return fmt.Sprintf("%v/%d", uint32ToIPV4(addr), prefixLen), nil
+	case IPv6:
+		if prefixLen < 0 || prefixLen > widthUInt128 {
+			return "", &PrefixLengthError{Prefix: prefixLen, Min: 0, Max: widthUInt128}
+		}
+		addr, ok := bestFit6(remove6(a.pool6.copy(), a.used6.copy()), uint(prefixLen))
+		if !ok {
+			return "", ErrNoSpace
+		}
+		a.used6 = coalesce6(append(a.used6, &cidrBlock6{first: addr, last: broadcast6(addr, uint(prefixLen))}))
+		return fmt.Sprintf("%v/%d", uint128ToIPV6(addr), prefixLen), nil
+	}
+
+	return "", fmt.Errorf("%w: %v", ErrInvalidFamily, family)
+}
+
+// AllocateSpecific allocates the CIDR block, which must be free and within the pools.
+// It returns ErrNotAvailable otherwise.
+func (a *Allocator) AllocateSpecific(cidr string) error {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return &ParseError{Kind: KindCIDR, Input: cidr, Index: -1}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	block4s, block6s := ipNets{network}.toBlocks()
+	if len(block4s) > 0 {
+		free := remove4(a.pool4.copy(), a.used4.copy())
+		if len(remove4(block4s.copy(), free)) > 0 {
+			return ErrNotAvailable
+		}
+		a.used4 = coalesce4(append(a.used4, block4s...))
+	} else {
+		free := remove6(a.pool6.copy(), a.used6.copy())
+		if len(remove6(block6s.copy(), free)) > 0 {
+			return ErrNotAvailable
+		}
+		a.used6 = coalesce6(append(a.used6, block6s...))
+	}
+
+	return nil
+}
+
+// Release returns the CIDR block to the free space. The whole block must be allocated,
+// though it need not be a block returned by Allocate. It returns ErrNotAllocated otherwise.
+func (a *Allocator) Release(cidr string) error {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return &ParseError{Kind: KindCIDR, Input: cidr, Index: -1}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	block4s, block6s := ipNets{network}.toBlocks()
+	if len(block4s) > 0 {
+		if len(remove4(block4s.copy(), a.used4.copy())) > 0 {
+			return ErrNotAllocated
+		}
+		a.used4 = remove4(a.used4, block4s)
+	} else {
+		if len(remove6(block6s.copy(), a.used6.copy())) > 0 {
+			return ErrNotAllocated
+		}
+		a.used6 = remove6(a.used6, block6s)
+	}
+
+	return nil
+}
+
+// Free returns the smallest possible list of CIDRs covering the free space, IPv4 blocks first.
+func (a *Allocator) Free() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	free := IPSet{block4s: remove4(a.pool4.copy(), a.used4.copy()), block6s: remove6(a.pool6.copy(), a.used6.copy())}
+	return free.CIDRs()
+}
+
+// Used returns the smallest possible list of CIDRs covering the allocated space, IPv4 blocks first.
+func (a *Allocator) Used() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	used := IPSet{block4s: a.used4.copy(), block6s: a.used6.copy()}
+	return used.CIDRs()
+}
@@ -0,0 +1,161 @@
+// go test -v -run="TestAllocator"
+
+package cidrman
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAllocator(t *testing.T) {
+	a, err := NewAllocator([]string{"10.0.0.0/24", "10.0.2.0/25", "2001:db8::/48"})
+	if err != nil {
+		t.Fatalf("NewAllocator failed: %s", err.Error())
+	}
+
+	type TestCase struct {
+		Name   string
+		Call   func() (string, error)
+		Output string
+		Error  error
+		Free   []string
+		Used   []string
+	}
+
+	allocate := func(family Family, prefixLen int) func() (string, error) {
+		return func() (string, error) { return a.Allocate(family, prefixLen) }
+	}
+	specific := func(cidr string) func() (string, error) {
+		return func() (string, error) { return "", a.AllocateSpecific(cidr) }
+	}
+	release := func(cidr string) func() (string, error) {
+		return func() (string, error) { return "", a.Release(cidr) }
+	}
+
+	testCases := []TestCase{
+		{
+			// Best fit picks the smaller pool.
+			Name:   "Allocate /26",
+			Call:   allocate(IPv4, 26),
+			Output: "10.0.2.0/26",
+			Free:   []string{"10.0.0.0/24", "10.0.2.64/26", "2001:db8::/48"},
+			Used:   []string{"10.0.2.0/26"},
+		},
+		{
+			Name:   "Allocate /25",
+			Call:   allocate(IPv4, 25),
+			Output: "10.0.0.0/25",
+			Free:   []string{"10.0.0.128/25", "10.0.2.64/26", "2001:db8::/48"},
+			Used:   []string{"10.0.0.0/25", "10.0.2.0/26"},
+		},
+		{
+			Name:   "Allocate /28",
+			Call:   allocate(IPv4, 28),
+			Output: "10.0.2.64/28",
+			Free:   []string{"10.0.0.128/25", "10.0.2.80/28", "10.0.2.96/27", "2001:db8::/48"},
+			Used:   []string{"10.0.0.0/25", "10.0.2.0/26", "10.0.2.64/28"},
+		},
+		{
+			Name:  "Allocate /24 exhausted",
+			Call:  allocate(IPv4, 24),
+			Error: ErrNoSpace,
+			Free:  []string{"10.0.0.128/25", "10.0.2.80/28", "10.0.2.96/27", "2001:db8::/48"},
+			Used:  []string{"10.0.0.0/25", "10.0.2.0/26", "10.0.2.64/28"},
+		},
+		{
+			Name:  "Allocate invalid family",
+			Call:  allocate(Family(5), 24),
+			Error: ErrInvalidFamily,
+			Free:  []string{"10.0.0.128/25", "10.0.2.80/28", "10.0.2.96/27", "2001:db8::/48"},
+			Used:  []string{"10.0.0.0/25", "10.0.2.0/26", "10.0.2.64/28"},
+		},
+		{
+			Name:  "Allocate /33",
+			Call:  allocate(IPv4, 33),
+			Error: &PrefixLengthError{Prefix: 33, Min: 0, Max: 32},
+			Free:  []string{"10.0.0.128/25", "10.0.2.80/28", "10.0.2.96/27", "2001:db8::/48"},
+			Used:  []string{"10.0.0.0/25", "10.0.2.0/26", "10.0.2.64/28"},
+		},
+		{
+			Name:  "AllocateSpecific allocated",
+			Call:  specific("10.0.0.64/26"),
+			Error: ErrNotAvailable,
+			Free:  []string{"10.0.0.128/25", "10.0.2.80/28", "10.0.2.96/27", "2001:db8::/48"},
+			Used:  []string{"10.0.0.0/25", "10.0.2.0/26", "10.0.2.64/28"},
+		},
+		{
+			Name:  "AllocateSpecific outside pools",
+			Call:  specific("10.0.1.0/28"),
+			Error: ErrNotAvailable,
+			Free:  []string{"10.0.0.128/25", "10.0.2.80/28", "10.0.2.96/27", "2001:db8::/48"},
+			Used:  []string{"10.0.0.0/25", "10.0.2.0/26", "10.0.2.64/28"},
+		},
+		{
+			Name: "AllocateSpecific",
+			Call: specific("10.0.0.128/25"),
+			Free: []string{"10.0.2.80/28", "10.0.2.96/27", "2001:db8::/48"},
+			Used: []string{"10.0.0.0/24", "10.0.2.0/26", "10.0.2.64/28"},
+		},
+		{
+			Name:   "Allocate IPv6 /64",
+			Call:   allocate(IPv6, 64),
+			Output: "2001:db8::/64",
+			Free: []string{
+				"10.0.2.80/28", "10.0.2.96/27",
+				"2001:db8:0:1::/64", "2001:db8:0:2::/63", "2001:db8:0:4::/62", "2001:db8:0:8::/61",
+				"2001:db8:0:10::/60", "2001:db8:0:20::/59", "2001:db8:0:40::/58", "2001:db8:0:80::/57",
+				"2001:db8:0:100::/56", "2001:db8:0:200::/55", "2001:db8:0:400::/54", "2001:db8:0:800::/53",
+				"2001:db8:0:1000::/52", "2001:db8:0:2000::/51", "2001:db8:0:4000::/50", "2001:db8:0:8000::/49",
+			},
+			Used: []string{"10.0.0.0/24", "10.0.2.0/26", "10.0.2.64/28", "2001:db8::/64"},
+		},
+		{
+			Name:  "Release unallocated",
+			Call:  release("10.0.2.80/28"),
+			Error: ErrNotAllocated,
+			Free: []string{
+				"10.0.2.80/28", "10.0.2.96/27",
+				"2001:db8:0:1::/64", "2001:db8:0:2::/63", "2001:db8:0:4::/62", "2001:db8:0:8::/61",
+				"2001:db8:0:10::/60", "2001:db8:0:20::/59", "2001:db8:0:40::/58", "2001:db8:0:80::/57",
+				"2001:db8:0:100::/56", "2001:db8:0:200::/55", "2001:db8:0:400::/54", "2001:db8:0:800::/53",
+				"2001:db8:0:1000::/52", "2001:db8:0:2000::/51", "2001:db8:0:4000::/50", "2001:db8:0:8000::/49",
+			},
+			Used: []string{"10.0.0.0/24", "10.0.2.0/26", "10.0.2.64/28", "2001:db8::/64"},
+		},
+		{
+			// Released blocks coalesce with the free space around them.
+			Name: "Release",
+			Call: release("2001:db8::/64"),
+			Free: []string{"10.0.2.80/28", "10.0.2.96/27", "2001:db8::/48"},
+			Used: []string{"10.0.0.0/24", "10.0.2.0/26", "10.0.2.64/28"},
+		},
+		{
+			Name: "Release part of an allocation",
+			Call: release("10.0.2.64/28"),
+			Free: []string{"10.0.2.64/26", "2001:db8::/48"},
+			Used: []string{"10.0.0.0/24", "10.0.2.0/26"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		output, err := testCase.Call()
+		if testCase.Error != nil {
+			var prefixErr *PrefixLengthError
+			if !errors.Is(err, testCase.Error) && !(errors.As(err, &prefixErr) && reflect.DeepEqual(testCase.Error, prefixErr)) {
+				t.Errorf("%s expected error: %v, got: %v", testCase.Name, testCase.Error, err)
+			}
+		} else if err != nil {
+			t.Errorf("%s failed: %s", testCase.Name, err.Error())
+		}
+		if output != testCase.Output {
+			t.Errorf("%s expected: %#v, got: %#v", testCase.Name, testCase.Output, output)
+		}
+		if free := a.Free(); !reflect.DeepEqual(testCase.Free, free) {
+			t.Errorf("%s expected free: %#v, got: %#v", testCase.Name, testCase.Free, free)
+		}
+		if used := a.Used(); !reflect.DeepEqual(testCase.Used, used) {
+			t.Errorf("%s expected used: %#v, got: %#v", testCase.Name, testCase.Used, used)
+		}
+	}
+}
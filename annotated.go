@@ -0,0 +1,106 @@
+package cidrman
+
+import (
+	"net"
+	"sort"
+)
+
+// Annotated is a CIDR block carrying a value, such as the feed it came from.
+type Annotated[T any] struct {
+	CIDR  string
+	Value T
+}
+
+// annotate4 assigns each IPv4 input block to the merged network containing it,
+// combining the values of the inputs per network.
+func annotate4[T any](merged []*net.IPNet, blocks cidrBlock4s, values []T, combine func(T, T) T) []Annotated[T] {
+	order := make([]int, len(blocks))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return blocks[order[i]].first < blocks[order[j]].first })
+
+	annotated := make([]Annotated[T], 0, len(merged))
+	next := 0
+	for _, network := range merged {
+		block := newBlock4(network.IP, network.Mask)
+		output := Annotated[T]{CIDR: network.String()}
+		for i := 0; next < len(order) && blocks[order[next]].first <= block.last; i, next = i+1, next+1 {
+			if i == 0 {
+				output.Value = values[order[next]]
+			} else {
+				output.Value = combine(output.Value, values[order[next]])
+			}
+		}
+		annotated = append(annotated, output)
+	}
+
+	return annotated
+}
+
+// annotate6 assigns each IPv6 input block to the merged network containing it,
+// combining the values of the inputs per network.
+func annotate6[T any](merged []*net.IPNet, blocks cidrBlock6s, values []T, combine func(T, T) T) []Annotated[T] {
+	order := make([]int, len(blocks))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return blocks[order[i]].first.cmp(blocks[order[j]].first) < 0 })
+
+	annotated := make([]Annotated[T], 0, len(merged))
+	next := 0
+	for _, network := range merged {
+		block := newBlock6(network.IP, network.Mask)
+		output := Annotated[T]{CIDR: network.String()}
+		for i := 0; next < len(order) && blocks[order[next]].first.cmp(block.last) <= 0; i, next = i+1, next+1 {
+			if i == 0 {
+				output.Value = values[order[next]]
+			} else {
+				output.Value = combine(output.Value, values[order[next]])
+			}
+		}
+		annotated = append(annotated, output)
+	}
+
+	return annotated
+}
+
+// MergeAnnotated accepts a list of annotated CIDR blocks and merges them into the smallest possible
+// list of CIDRs, like MergeCIDRs. Each output CIDR carries the values of the input CIDR blocks it covers,
+// folded together with combine in ascending address order of the inputs, then input order.
+func MergeAnnotated[T any](inputs []Annotated[T], combine func(T, T) T) ([]Annotated[T], error) {
+	if inputs == nil {
+		return nil, nil
+	}
+
+	var block4s cidrBlock4s
+	var block6s cidrBlock6s
+	var values4, values6 []T
+	for i, input := range inputs {
+		_, network, err := net.ParseCIDR(input.CIDR)
+		if err != nil {
+			return nil, &ParseError{Kind: KindCIDR, Input: input.CIDR, Index: i}
+		}
+
+		if ip4 := network.IP.To4(); ip4 != nil {
+			block4s = append(block4s, newBlock4(ip4, network.Mask))
+			values4 = append(values4, input.Value)
+		} else {
+			block6s = append(block6s, newBlock6(network.IP.To16(), network.Mask))
+			values6 = append(values6, input.Value)
+		}
+	}
+
+	// Merging coalesces the blocks in place, so merge copies.
+	merged4, err := merge4(block4s.copy())
+	if err != nil {
+		return nil, err
+	}
+	merged6, err := merge6(block6s.copy())
+	if err != nil {
+		return nil, err
+	}
+
+	annotated := annotate4(merged4, block4s, values4, combine)
+	return append(annotated, annotate6(merged6, block6s, values6, combine)...), nil
+}
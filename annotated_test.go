@@ -0,0 +1,101 @@
+// go test -v -run="TestMergeAnnotated"
+
+package cidrman
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeAnnotated(t *testing.T) {
+	type TestCase struct {
+		Input  []Annotated[[]string]
+		Output []Annotated[[]string]
+		Error  bool
+	}
+
+	testCases := []TestCase{
+		{
+			Input:  nil,
+			Output: nil,
+			Error:  false,
+		},
+		{
+			Input:  []Annotated[[]string]{},
+			Output: []Annotated[[]string]{},
+			Error:  false,
+		},
+		{
+			Input: []Annotated[[]string]{
+				{CIDR: "10.0.0.0/33", Value: []string{"a"}},
+			},
+			Output: nil,
+			Error:  true,
+		},
+		{
+			Input: []Annotated[[]string]{
+				{CIDR: "192.0.2.128/25", Value: []string{"feed2"}},
+				{CIDR: "192.0.2.0/25", Value: []string{"feed1"}},
+				{CIDR: "192.0.2.0/24", Value: []string{"feed3"}},
+				{CIDR: "198.51.100.0/24", Value: []string{"feed1"}},
+			},
+			Output: []Annotated[[]string]{
+				{CIDR: "192.0.2.0/24", Value: []string{"feed1", "feed3", "feed2"}},
+				{CIDR: "198.51.100.0/24", Value: []string{"feed1"}},
+			},
+			Error: false,
+		},
+		{
+			// A merged range splitting into several CIDRs.
+			Input: []Annotated[[]string]{
+				{CIDR: "10.0.0.0/24", Value: []string{"a"}},
+				{CIDR: "10.0.1.0/24", Value: []string{"b"}},
+				{CIDR: "10.0.2.0/24", Value: []string{"c"}},
+			},
+			Output: []Annotated[[]string]{
+				{CIDR: "10.0.0.0/23", Value: []string{"a", "b"}},
+				{CIDR: "10.0.2.0/24", Value: []string{"c"}},
+			},
+			Error: false,
+		},
+		{
+			Input: []Annotated[[]string]{
+				{CIDR: "2001:db8:0:3::/64", Value: []string{"b"}},
+				{CIDR: "2001:db8:0:2::/64", Value: []string{"a"}},
+				{CIDR: "192.0.2.1/32", Value: []string{"c"}},
+			},
+			Output: []Annotated[[]string]{
+				{CIDR: "192.0.2.1/32", Value: []string{"c"}},
+				{CIDR: "2001:db8:0:2::/63", Value: []string{"a", "b"}},
+			},
+			Error: false,
+		},
+	}
+
+	combine := func(a, b []string) []string {
+		return append(append([]string(nil), a...), b...)
+	}
+
+	for _, testCase := range testCases {
+		output, err := MergeAnnotated(testCase.Input, combine)
+		if err != nil {
+			if !testCase.Error {
+				t.Errorf("MergeAnnotated(%#v) failed: %s", testCase.Input, err.Error())
+			}
+			continue
+		}
+		if !reflect.DeepEqual(testCase.Output, output) {
+			t.Errorf("MergeAnnotated(%#v) expected: %#v, got: %#v", testCase.Input, testCase.Output, output)
+		}
+	}
+
+	// Counting the inputs per output.
+	counted, err := MergeAnnotated([]Annotated[int]{
+		{CIDR: "10.0.0.0/8", Value: 1},
+		{CIDR: "10.1.0.0/16", Value: 1},
+		{CIDR: "10.2.0.0/16", Value: 1},
+	}, func(a, b int) int { return a + b })
+	if err != nil || len(counted) != 1 || counted[0].Value != 3 {
+		t.Errorf("MergeAnnotated count expected: 3, got: %#v, %v", counted, err)
+	}
+}
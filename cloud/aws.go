@@ -0,0 +1,42 @@
+package cloud
+
+import (
+	"io"
+)
+
+// awsRanges is the format of the AWS ip-ranges.json file.
+type awsRanges struct {
+	Prefixes []struct {
+		IPPrefix string `json:"ip_prefix"`
+		Region   string `json:"region"`
+		Service  string `json:"service"`
+	} `json:"prefixes"`
+	IPv6Prefixes []struct {
+		IPv6Prefix string `json:"ipv6_prefix"`
+		Region     string `json:"region"`
+		Service    string `json:"service"`
+	} `json:"ipv6_prefixes"`
+}
+
+// AWS reads the AWS ip-ranges.json file and returns the merged IPv4 and IPv6 prefixes
+// matching the service and region of the filter.
+func AWS(r io.Reader, filter Filter) ([]string, error) {
+	var ranges awsRanges
+	if err := decode(r, "AWS", &ranges); err != nil {
+		return nil, err
+	}
+
+	var cidrs []string
+	for _, prefix := range ranges.Prefixes {
+		if match(filter.Service, prefix.Service) && match(filter.Region, prefix.Region) {
+			cidrs = append(cidrs, prefix.IPPrefix)
+		}
+	}
+	for _, prefix := range ranges.IPv6Prefixes {
+		if match(filter.Service, prefix.Service) && match(filter.Region, prefix.Region) {
+			cidrs = append(cidrs, prefix.IPv6Prefix)
+		}
+	}
+
+	return merge(cidrs)
+}
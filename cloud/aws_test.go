@@ -0,0 +1,53 @@
+// go test -v -run="TestAWS"
+
+package cloud
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAWS(t *testing.T) {
+	type TestCase struct {
+		Filter Filter
+		Output []string
+	}
+
+	testCases := []TestCase{
+		{
+			Filter: Filter{},
+			Output: []string{"3.5.140.0/22", "13.48.0.0/14", "52.95.170.0/23", "2406:da12::/36", "2a05:d016::/35"},
+		},
+		{
+			Filter: Filter{Service: "ec2", Region: "EU-NORTH-1"},
+			Output: []string{"13.48.0.0/14", "2a05:d016::/35"},
+		},
+		{
+			Filter: Filter{Service: "S3"},
+			Output: []string{"52.95.170.0/23"},
+		},
+		{
+			Filter: Filter{Region: "us-east-1"},
+			Output: []string{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		output, err := AWS(openFixture(t, "aws-ip-ranges.json"), testCase.Filter)
+		if err != nil {
+			t.Errorf("AWS(%#v) failed: %s", testCase.Filter, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(testCase.Output, output) {
+			t.Errorf("AWS(%#v) expected: %#v, got: %#v", testCase.Filter, testCase.Output, output)
+		}
+	}
+
+	if _, err := AWS(strings.NewReader(`{"prefixes": [`), Filter{}); err == nil {
+		t.Errorf("AWS() expected error for truncated JSON")
+	}
+	if _, err := AWS(strings.NewReader(`{"prefixes": [{"ip_prefix": "3.5.140.0/33"}]}`), Filter{}); err == nil {
+		t.Errorf("AWS() expected error for invalid prefix")
+	}
+}
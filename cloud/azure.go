@@ -0,0 +1,35 @@
+package cloud
+
+import (
+	"io"
+)
+
+// azureServiceTags is the format of the Azure ServiceTags JSON file.
+type azureServiceTags struct {
+	Values []struct {
+		Name       string `json:"name"`
+		Properties struct {
+			Region          string   `json:"region"`
+			SystemService   string   `json:"systemService"`
+			AddressPrefixes []string `json:"addressPrefixes"`
+		} `json:"properties"`
+	} `json:"values"`
+}
+
+// Azure reads an Azure ServiceTags JSON file and returns the merged IPv4 and IPv6 address prefixes
+// of the service tags matching the tag name, system service and region of the filter.
+func Azure(r io.Reader, filter Filter) ([]string, error) {
+	var tags azureServiceTags
+	if err := decode(r, "Azure", &tags); err != nil {
+		return nil, err
+	}
+
+	var cidrs []string
+	for _, tag := range tags.Values {
+		if match(filter.Tag, tag.Name) && match(filter.Service, tag.Properties.SystemService) && match(filter.Region, tag.Properties.Region) {
+			cidrs = append(cidrs, tag.Properties.AddressPrefixes...)
+		}
+	}
+
+	return merge(cidrs)
+}
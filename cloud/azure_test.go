@@ -0,0 +1,54 @@
+// go test -v -run="TestAzure"
+
+package cloud
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAzure(t *testing.T) {
+	type TestCase struct {
+		Filter Filter
+		Output []string
+	}
+
+	testCases := []TestCase{
+		{
+			Filter: Filter{},
+			Output: []string{"13.69.40.0/23", "13.69.192.0/18", "13.95.96.0/24", "2603:1020:5::/48"},
+		},
+		{
+			Filter: Filter{Tag: "AzureCloud.northeurope"},
+			Output: []string{"13.69.192.0/18", "2603:1020:5::/48"},
+		},
+		{
+			Filter: Filter{Service: "AzureStorage"},
+			Output: []string{"13.69.40.0/23", "13.95.96.0/24", "2603:1020:5::/48"},
+		},
+		{
+			Filter: Filter{Service: "azurestorage", Region: "westeurope"},
+			Output: []string{"13.95.96.0/24"},
+		},
+		{
+			Filter: Filter{Tag: "AzureFrontDoor.Backend"},
+			Output: []string{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		output, err := Azure(openFixture(t, "azure-service-tags.json"), testCase.Filter)
+		if err != nil {
+			t.Errorf("Azure(%#v) failed: %s", testCase.Filter, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(testCase.Output, output) {
+			t.Errorf("Azure(%#v) expected: %#v, got: %#v", testCase.Filter, testCase.Output, output)
+		}
+	}
+
+	if _, err := Azure(strings.NewReader(`{"values": {}}`), Filter{}); err == nil {
+		t.Errorf("Azure() expected error for invalid values")
+	}
+}
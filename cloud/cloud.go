@@ -0,0 +1,54 @@
+// Package cloud imports the IP ranges published by cloud providers, as saved
+// locally from their download locations, into merged lists of CIDR blocks:
+//
+//   - AWS: ip-ranges.json from https://ip-ranges.amazonaws.com/ip-ranges.json
+//   - GCP: cloud.json from https://www.gstatic.com/ipranges/cloud.json
+//   - Azure: ServiceTags_Public_*.json from the Azure IP Ranges and Service Tags download
+//   - Cloudflare: the plain lists https://www.cloudflare.com/ips-v4 and ips-v6
+//
+// The ranges are filtered by service, region or service tag and merged with MergeCIDRs,
+// so the result is the smallest possible list of CIDR blocks, IPv4 before IPv6.
+package cloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Netnod/go-cidrman"
+)
+
+// Filter selects the ranges to import. Fields are compared ignoring case and an empty field matches all ranges.
+// Providers without a field in their data ignore it.
+type Filter struct {
+	// Service is the AWS service, such as EC2, the GCP service, such as "Google Cloud",
+	// or the Azure system service, such as AzureStorage.
+	Service string
+	// Region is the AWS region, such as eu-north-1, the GCP scope, such as europe-north1,
+	// or the Azure region, such as northeurope.
+	Region string
+	// Tag is the Azure service tag name, such as AzureCloud.northeurope.
+	Tag string
+}
+
+// match reports whether the value matches a field of the filter.
+func match(field, value string) bool {
+	return field == "" || strings.EqualFold(field, value)
+}
+
+// decode decodes the JSON document of the provider into v.
+func decode(r io.Reader, provider string, v interface{}) error {
+	if err := json.NewDecoder(r).Decode(v); err != nil {
+		return fmt.Errorf("Invalid %s IP ranges: %w", provider, err)
+	}
+	return nil
+}
+
+// merge merges the imported CIDR blocks, returning an empty list if there are none.
+func merge(cidrs []string) ([]string, error) {
+	if cidrs == nil {
+		cidrs = make([]string, 0)
+	}
+	return cidrman.MergeCIDRs(cidrs)
+}
@@ -0,0 +1,32 @@
+package cloud
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Cloudflare reads the Cloudflare plain lists of IPv4 or IPv6 ranges, one CIDR block per line,
+// and returns the merged CIDR blocks. Blank lines and comments starting with '#' are ignored.
+// The ranges of both lists are merged together if both are passed.
+func Cloudflare(readers ...io.Reader) ([]string, error) {
+	var cidrs []string
+	for _, r := range readers {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			text := scanner.Text()
+			if i := strings.IndexByte(text, '#'); i >= 0 {
+				text = text[:i]
+			}
+			if text = strings.TrimSpace(text); text != "" {
+				cidrs = append(cidrs, text)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("Invalid Cloudflare IP ranges: %w", err)
+		}
+	}
+
+	return merge(cidrs)
+}
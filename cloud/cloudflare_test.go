@@ -0,0 +1,53 @@
+// go test -v -run="TestCloudflare"
+
+package cloud
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCloudflare(t *testing.T) {
+	output, err := Cloudflare(openFixture(t, "cloudflare-ips-v4.txt"), openFixture(t, "cloudflare-ips-v6.txt"))
+	if err != nil {
+		t.Fatalf("Cloudflare() failed: %s", err.Error())
+	}
+
+	expected := []string{
+		"103.21.244.0/22",
+		"103.22.200.0/22",
+		"103.31.4.0/22",
+		"104.16.0.0/13",
+		"104.24.0.0/14",
+		"108.162.192.0/18",
+		"131.0.72.0/22",
+		"141.101.64.0/18",
+		"162.158.0.0/15",
+		"172.64.0.0/13",
+		"173.245.48.0/20",
+		"188.114.96.0/20",
+		"190.93.240.0/20",
+		"197.234.240.0/22",
+		"198.41.128.0/17",
+		"2400:cb00::/32",
+		"2405:8100::/32",
+		"2405:b500::/32",
+		"2606:4700::/32",
+		"2803:f800::/32",
+		"2a06:98c0::/29",
+		"2c0f:f248::/32",
+	}
+	if !reflect.DeepEqual(expected, output) {
+		t.Errorf("Cloudflare() expected: %#v, got: %#v", expected, output)
+	}
+
+	output, err = Cloudflare(strings.NewReader("# Cloudflare\n\n192.0.2.0/25\n192.0.2.128/25  # Trailing comment\n"))
+	if err != nil || !reflect.DeepEqual([]string{"192.0.2.0/24"}, output) {
+		t.Errorf("Cloudflare() expected: [192.0.2.0/24], got: %#v, %v", output, err)
+	}
+
+	if _, err := Cloudflare(strings.NewReader("192.0.2.0/25\nnot-a-cidr\n")); err == nil {
+		t.Errorf("Cloudflare() expected error for invalid CIDR block")
+	}
+}
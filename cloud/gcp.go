@@ -0,0 +1,39 @@
+package cloud
+
+import (
+	"io"
+)
+
+// gcpRanges is the format of the GCP cloud.json file. Each prefix has either an IPv4 or an IPv6 prefix.
+type gcpRanges struct {
+	Prefixes []struct {
+		IPv4Prefix string `json:"ipv4Prefix"`
+		IPv6Prefix string `json:"ipv6Prefix"`
+		Service    string `json:"service"`
+		Scope      string `json:"scope"`
+	} `json:"prefixes"`
+}
+
+// GCP reads the GCP cloud.json file and returns the merged IPv4 and IPv6 prefixes
+// matching the service and region (scope) of the filter.
+func GCP(r io.Reader, filter Filter) ([]string, error) {
+	var ranges gcpRanges
+	if err := decode(r, "GCP", &ranges); err != nil {
+		return nil, err
+	}
+
+	var cidrs []string
+	for _, prefix := range ranges.Prefixes {
+		if !match(filter.Service, prefix.Service) || !match(filter.Region, prefix.Scope) {
+			continue
+		}
+		if prefix.IPv4Prefix != "" {
+			cidrs = append(cidrs, prefix.IPv4Prefix)
+		}
+		if prefix.IPv6Prefix != "" {
+			cidrs = append(cidrs, prefix.IPv6Prefix)
+		}
+	}
+
+	return merge(cidrs)
+}
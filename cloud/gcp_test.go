@@ -0,0 +1,46 @@
+// go test -v -run="TestGCP"
+
+package cloud
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGCP(t *testing.T) {
+	type TestCase struct {
+		Filter Filter
+		Output []string
+	}
+
+	testCases := []TestCase{
+		{
+			Filter: Filter{},
+			Output: []string{"34.1.208.0/20", "34.88.0.0/15", "2600:1900:4150::/44", "2600:1900:8000::/44"},
+		},
+		{
+			Filter: Filter{Service: "Google Cloud", Region: "europe-north1"},
+			Output: []string{"34.88.0.0/15", "2600:1900:4150::/44"},
+		},
+		{
+			Filter: Filter{Service: "Google Workspace"},
+			Output: []string{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		output, err := GCP(openFixture(t, "gcp-cloud.json"), testCase.Filter)
+		if err != nil {
+			t.Errorf("GCP(%#v) failed: %s", testCase.Filter, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(testCase.Output, output) {
+			t.Errorf("GCP(%#v) expected: %#v, got: %#v", testCase.Filter, testCase.Output, output)
+		}
+	}
+
+	if _, err := GCP(strings.NewReader(`not json`), Filter{}); err == nil {
+		t.Errorf("GCP() expected error for invalid JSON")
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// line is a non-empty, non-comment input line and where it came from.
+type line struct {
+	text string
+	name string
+	num  int
+}
+
+// errorf returns an error prefixed with the location of the line.
+func (l line) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s:%d: %s", l.name, l.num, fmt.Sprintf(format, args...))
+}
+
+// readLines reads the lines of r, dropping comments starting with '#' and blank lines.
+func readLines(r io.Reader, name string) ([]line, error) {
+	var lines []line
+
+	scanner := bufio.NewScanner(r)
+	num := 0
+	for scanner.Scan() {
+		num++
+		text := scanner.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		lines = append(lines, line{text: text, name: name, num: num})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+
+	return lines, nil
+}
+
+// readInputs reads the lines of the named files, or of stdin when no files or "-" are named.
+func readInputs(files []string, stdin io.Reader) ([]line, error) {
+	if len(files) == 0 {
+		return readLines(stdin, "<stdin>")
+	}
+
+	var lines []line
+	for _, file := range files {
+		if file == "-" {
+			l, err := readLines(stdin, "<stdin>")
+			if err != nil {
+				return nil, err
+			}
+			lines = append(lines, l...)
+			continue
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		l, err := readLines(f, file)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, l...)
+	}
+
+	return lines, nil
+}
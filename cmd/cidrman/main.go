@@ -0,0 +1,434 @@
+// Command cidrman merges, converts, splits and excludes CIDR blocks.
+//
+// Input is read from the files named on the command line, or from stdin,
+// one CIDR block, address or range per line. Blank lines and comments
+// starting with '#' are ignored. Results are written to stdout.
+// CIDR blocks may also be written with a netmask or Cisco-style wildcard
+// mask, as in "10.0.0.0 255.255.0.0" or "10.0.0.0 0.0.255.255".
+//
+// Usage:
+//
+//	cidrman merge [-strict] [-format cidr|netmask|wildcard] [file ...]
+//	cidrman range [file ...]
+//	cidrman subnets -prefix N [file ...]
+//	cidrman exclude -exclude file [file ...]
+//	cidrman diff -old file [file ...]
+//	cidrman contains [-v] -cidrs file [file ...]
+//	cidrman info [file ...]
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/Netnod/go-cidrman"
+)
+
+// runFunc runs a subcommand on its non-flag arguments.
+type runFunc func(args []string, stdin io.Reader, stdout io.Writer) error
+
+// command is a cidrman subcommand. Its setup function defines the flags and returns the run function.
+type command struct {
+	name  string
+	args  string
+	help  string
+	setup func(flags *flag.FlagSet) runFunc
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "merge", args: "[-strict] [-format cidr|netmask|wildcard] [file ...]", help: "merge CIDR blocks, addresses and \"start-end\" ranges into the smallest possible list", setup: mergeCommand},
+		{name: "range", args: "[file ...]", help: "convert \"start-end\" or \"start end\" address ranges to CIDR blocks", setup: rangeCommand},
+		{name: "subnets", args: "-prefix N [file ...]", help: "divide CIDR blocks into subnets of the prefix length", setup: subnetsCommand},
+		{name: "exclude", args: "-exclude file [file ...]", help: "remove the CIDR blocks in the exclude file from the CIDR blocks", setup: excludeCommand},
+		{name: "diff", args: "-old file [file ...]", help: "print the address space added and removed since the CIDR blocks in the old file", setup: diffCommand},
+		{name: "contains", args: "[-v] -cidrs file [file ...]", help: "print the addresses within the CIDR blocks in the cidrs file", setup: containsCommand},
+		{name: "info", args: "[file ...]", help: "print the network, netmask, broadcast and size of CIDR blocks", setup: infoCommand},
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// usage writes the list of subcommands.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: cidrman <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Input is read from the named files or stdin, one entry per line.")
+	fmt.Fprintln(w, "Blank lines and comments starting with '#' are ignored.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-9s %s\n", cmd.name, cmd.help)
+	}
+}
+
+// run runs the cidrman command line and returns the exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+
+		flags := flag.NewFlagSet("cidrman "+cmd.name, flag.ContinueOnError)
+		flags.SetOutput(stderr)
+		flags.Usage = func() {
+			fmt.Fprintf(stderr, "usage: cidrman %s %s\n", cmd.name, cmd.args)
+			flags.PrintDefaults()
+		}
+		runCmd := cmd.setup(flags)
+		if err := flags.Parse(args[1:]); err != nil {
+			if err == flag.ErrHelp {
+				return 0
+			}
+			return 2
+		}
+
+		if err := runCmd(flags.Args(), stdin, stdout); err != nil {
+			fmt.Fprintf(stderr, "cidrman %s: %s\n", cmd.name, err)
+			return 1
+		}
+		return 0
+	}
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		usage(stdout)
+		return 0
+	}
+
+	fmt.Fprintf(stderr, "cidrman: unknown command %q\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+// parseNets parses the lines as CIDR blocks.
+func parseNets(lines []line) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, l := range lines {
+		network, err := cidrman.ParseMaskedCIDR(l.text)
+		if errors.Is(err, cidrman.ErrNonContiguousMask) {
+			return nil, l.errorf("non-contiguous mask in %q", l.text)
+		}
+		if err != nil {
+			return nil, l.errorf("invalid CIDR block %q", l.text)
+		}
+		nets = append(nets, network)
+	}
+
+	return nets, nil
+}
+
+// readNets reads and parses CIDR blocks from the named files or stdin.
+func readNets(files []string, stdin io.Reader) ([]*net.IPNet, error) {
+	lines, err := readInputs(files, stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseNets(lines)
+}
+
+// printNets writes the networks, one per line.
+func printNets(w io.Writer, nets []*net.IPNet) {
+	for _, n := range nets {
+		fmt.Fprintln(w, n)
+	}
+}
+
+func mergeCommand(flags *flag.FlagSet) runFunc {
+	strict := flags.Bool("strict", false, "reject CIDR blocks with host bits set")
+	format := flags.String("format", "cidr", "output notation: cidr, netmask or wildcard")
+
+	return func(args []string, stdin io.Reader, stdout io.Writer) error {
+		lines, err := readInputs(args, stdin)
+		if err != nil {
+			return err
+		}
+
+		notation, ok := notations[*format]
+		if !ok {
+			return fmt.Errorf("unknown format %q", *format)
+		}
+
+		// Blocks with a netmask or wildcard mask are passed on in slash notation,
+		// keeping the host bits for the strict check. Single addresses and ranges
+		// are merged in afterwards.
+		var cidrs, others []string
+		var cidrLines []line
+		for _, l := range lines {
+			switch {
+			case strings.Contains(l.text, "-"):
+				if _, _, err := cidrman.ParseRange(l.text); err != nil {
+					return l.errorf("invalid range %q: %s", l.text, err)
+				}
+				others = append(others, l.text)
+			case net.ParseIP(l.text) != nil:
+				others = append(others, l.text)
+			default:
+				if _, err := parseNets([]line{l}); err != nil {
+					return err
+				}
+				cidr, err := cidrman.MaskedToCIDR(l.text)
+				if err != nil {
+					return l.errorf("invalid CIDR block %q", l.text)
+				}
+				cidrs = append(cidrs, cidr)
+				cidrLines = append(cidrLines, l)
+			}
+		}
+
+		merged, _, err := cidrman.MergeCIDRsWithOptions(cidrs, cidrman.Options{Strict: *strict})
+		if err != nil {
+			var hostBitsErr *cidrman.HostBitsError
+			if errors.As(err, &hostBitsErr) {
+				l := cidrLines[hostBitsErr.Index]
+				return l.errorf("CIDR block %q has host bits set, expected %s", l.text, hostBitsErr.Network)
+			}
+			return err
+		}
+		if len(others) > 0 {
+			if merged, err = cidrman.MergeMixed(append(merged, others...)); err != nil {
+				return err
+			}
+		}
+
+		formatted, err := cidrman.FormatCIDRs(merged, notation)
+		if err != nil {
+			return err
+		}
+		for _, cidr := range formatted {
+			fmt.Fprintln(stdout, cidr)
+		}
+		return nil
+	}
+}
+
+// notations maps the -format values to notations.
+var notations = map[string]cidrman.Notation{
+	"cidr":     cidrman.NotationCIDR,
+	"netmask":  cidrman.NotationNetmask,
+	"wildcard": cidrman.NotationWildcard,
+}
+
+func rangeCommand(flags *flag.FlagSet) runFunc {
+	return runRange
+}
+
+func runRange(args []string, stdin io.Reader, stdout io.Writer) error {
+	lines, err := readInputs(args, stdin)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range lines {
+		var start, end net.IP
+		switch fields := strings.Fields(l.text); len(fields) {
+		case 1, 3:
+			var err error
+			start, end, err = cidrman.ParseRange(l.text)
+			if err != nil {
+				return l.errorf("invalid range %q: %s", l.text, err)
+			}
+		case 2:
+			start = net.ParseIP(fields[0])
+			if start == nil {
+				return l.errorf("invalid IP address %q", fields[0])
+			}
+			end = net.ParseIP(fields[1])
+			if end == nil {
+				return l.errorf("invalid IP address %q", fields[1])
+			}
+		default:
+			return l.errorf("invalid range %q, expected \"start-end\" or \"start end\"", l.text)
+		}
+
+		nets, err := cidrman.IPRangeToIPNets(start, end)
+		if err != nil {
+			return l.errorf("invalid range %q: %s", l.text, err)
+		}
+		printNets(stdout, nets)
+	}
+
+	return nil
+}
+
+func subnetsCommand(flags *flag.FlagSet) runFunc {
+	prefix := flags.Int("prefix", -1, "prefix length of the subnets")
+
+	return func(args []string, stdin io.Reader, stdout io.Writer) error {
+		if *prefix < 0 {
+			return fmt.Errorf("missing -prefix")
+		}
+
+		lines, err := readInputs(args, stdin)
+		if err != nil {
+			return err
+		}
+		nets, err := parseNets(lines)
+		if err != nil {
+			return err
+		}
+
+		for i, n := range nets {
+			subnets, err := cidrman.SubnetsIPNet(n, *prefix)
+			if errors.Is(err, cidrman.ErrTooManyBlocks) {
+				return lines[i].errorf("more than %d subnets of length %d in %s", cidrman.MaxSubnets, *prefix, n)
+			}
+			if err != nil {
+				return lines[i].errorf("%s", err)
+			}
+			printNets(stdout, subnets)
+		}
+
+		return nil
+	}
+}
+
+func excludeCommand(flags *flag.FlagSet) runFunc {
+	excludeFile := flags.String("exclude", "", "file of CIDR blocks to exclude")
+
+	return func(args []string, stdin io.Reader, stdout io.Writer) error {
+		if *excludeFile == "" {
+			return fmt.Errorf("missing -exclude")
+		}
+
+		exclude, err := readNets([]string{*excludeFile}, stdin)
+		if err != nil {
+			return err
+		}
+		base, err := readNets(args, stdin)
+		if err != nil {
+			return err
+		}
+
+		remaining, err := cidrman.RemoveIPNets(base, exclude)
+		if err != nil {
+			return err
+		}
+
+		printNets(stdout, remaining)
+		return nil
+	}
+}
+
+func diffCommand(flags *flag.FlagSet) runFunc {
+	oldFile := flags.String("old", "", "file of the old CIDR blocks")
+
+	return func(args []string, stdin io.Reader, stdout io.Writer) error {
+		if *oldFile == "" {
+			return fmt.Errorf("missing -old")
+		}
+
+		old, err := readNets([]string{*oldFile}, stdin)
+		if err != nil {
+			return err
+		}
+		current, err := readNets(args, stdin)
+		if err != nil {
+			return err
+		}
+
+		diff, err := cidrman.Diff(ipNetStrings(old), ipNetStrings(current))
+		if err != nil {
+			return err
+		}
+
+		newName := "<stdin>"
+		if len(args) > 0 {
+			newName = strings.Join(args, " ")
+		}
+		return diff.WriteUnified(stdout, *oldFile, newName)
+	}
+}
+
+// ipNetStrings returns the networks in CIDR notation.
+func ipNetStrings(nets []*net.IPNet) []string {
+	cidrs := make([]string, len(nets))
+	for i, n := range nets {
+		cidrs[i] = n.String()
+	}
+	return cidrs
+}
+
+func containsCommand(flags *flag.FlagSet) runFunc {
+	cidrsFile := flags.String("cidrs", "", "file of CIDR blocks to match against")
+	invert := flags.Bool("v", false, "print the addresses not within the CIDR blocks instead")
+
+	return func(args []string, stdin io.Reader, stdout io.Writer) error {
+		if *cidrsFile == "" {
+			return fmt.Errorf("missing -cidrs")
+		}
+
+		nets, err := readNets([]string{*cidrsFile}, stdin)
+		if err != nil {
+			return err
+		}
+		matcher := cidrman.NewMatcherFromIPNets(nets)
+
+		lines, err := readInputs(args, stdin)
+		if err != nil {
+			return err
+		}
+		for _, l := range lines {
+			ip := net.ParseIP(l.text)
+			if ip == nil {
+				return l.errorf("invalid IP address %q", l.text)
+			}
+			if matcher.Contains(ip) != *invert {
+				fmt.Fprintln(stdout, l.text)
+			}
+		}
+
+		return nil
+	}
+}
+
+func infoCommand(flags *flag.FlagSet) runFunc {
+	return runInfo
+}
+
+func runInfo(args []string, stdin io.Reader, stdout io.Writer) error {
+	nets, err := readNets(args, stdin)
+	if err != nil {
+		return err
+	}
+
+	for i, n := range nets {
+		if i > 0 {
+			fmt.Fprintln(stdout)
+		}
+
+		ones, bits := n.Mask.Size()
+		hostmask := make(net.IP, len(n.Mask))
+		broadcast := make(net.IP, len(n.IP))
+		for j := range n.Mask {
+			hostmask[j] = ^n.Mask[j]
+			broadcast[j] = n.IP[j] | hostmask[j]
+		}
+		size := big.NewInt(0).Lsh(big.NewInt(1), uint(bits-ones))
+
+		fmt.Fprintf(stdout, "cidr       %s\n", n)
+		fmt.Fprintf(stdout, "network    %s\n", n.IP)
+		fmt.Fprintf(stdout, "broadcast  %s\n", broadcast)
+		fmt.Fprintf(stdout, "netmask    %s\n", net.IP(n.Mask))
+		fmt.Fprintf(stdout, "hostmask   %s\n", hostmask)
+		fmt.Fprintf(stdout, "prefix     %d\n", ones)
+		fmt.Fprintf(stdout, "addresses  %s\n", size)
+	}
+
+	return nil
+}
@@ -0,0 +1,219 @@
+// go test -v -run="TestRun"
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	cidrsFile := filepath.Join(dir, "cidrs.txt")
+	if err := os.WriteFile(cidrsFile, []byte("# Allowed\n10.0.0.0/8\n2001:db8::/32\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	type TestCase struct {
+		Args   []string
+		Stdin  string
+		Stdout string
+		Stderr string
+		Status int
+	}
+
+	testCases := []TestCase{
+		{
+			Args:   nil,
+			Stderr: "usage: cidrman",
+			Status: 2,
+		},
+		{
+			Args:   []string{"frobnicate"},
+			Stderr: "unknown command",
+			Status: 2,
+		},
+		{
+			Args:   []string{"merge"},
+			Stdin:  "# Comment\n192.0.2.0/25\n\n192.0.2.128/25  # Trailing comment\n2001:db8::/33\n2001:db8:8000::/33\n",
+			Stdout: "192.0.2.0/24\n2001:db8::/32\n",
+		},
+		{
+			Args:   []string{"merge"},
+			Stdin:  "192.0.2.0/25\n\n192.0.2.300/25\n",
+			Stderr: "cidrman merge: <stdin>:3: invalid CIDR block \"192.0.2.300/25\"\n",
+			Status: 1,
+		},
+		{
+			Args:   []string{"merge", "-strict"},
+			Stdin:  "192.0.2.0/25\n10.1.2.3/8\n",
+			Stderr: "cidrman merge: <stdin>:2: CIDR block \"10.1.2.3/8\" has host bits set, expected 10.0.0.0/8\n",
+			Status: 1,
+		},
+		{
+			Args:   []string{"merge"},
+			Stdin:  "10.1.2.3/8\n",
+			Stdout: "10.0.0.0/8\n",
+		},
+		{
+			Args:   []string{"merge", "-format", "wildcard"},
+			Stdin:  "10.0.0.0 255.255.0.0\n10.1.0.0 0.0.255.255\n192.0.2.1/32\n2001:db8::/32\n",
+			Stdout: "10.0.0.0 0.1.255.255\n192.0.2.1 0.0.0.0\n2001:db8::/32\n",
+		},
+		{
+			Args:   []string{"merge", "-strict"},
+			Stdin:  "192.0.2.0 255.255.255.0\n10.1.2.3 255.0.0.0\n",
+			Stderr: "cidrman merge: <stdin>:2: CIDR block \"10.1.2.3 255.0.0.0\" has host bits set, expected 10.0.0.0/8\n",
+			Status: 1,
+		},
+		{
+			Args:   []string{"merge", "-strict"},
+			Stdin:  "10.1.2.3 0.255.255.255\n",
+			Stderr: "cidrman merge: <stdin>:1: CIDR block \"10.1.2.3 0.255.255.255\" has host bits set, expected 10.0.0.0/8\n",
+			Status: 1,
+		},
+		{
+			Args:   []string{"merge", "-strict"},
+			Stdin:  "10.0.0.0 255.0.0.0\n192.0.2.1 0.0.0.0\n",
+			Stdout: "10.0.0.0/8\n192.0.2.1/32\n",
+		},
+		{
+			Args:   []string{"merge"},
+			Stdin:  "10.0.1.0-10.0.1.255\n10.0.0.0/24\n10.0.2.0\n10.0.2.1-10.0.3.255\n2001:db8::1\n",
+			Stdout: "10.0.0.0/22\n2001:db8::1/128\n",
+		},
+		{
+			Args:   []string{"merge", "-strict", "-format", "netmask"},
+			Stdin:  "10.0.1.0-10.0.1.255\n10.0.0.0 255.255.255.0\n",
+			Stdout: "10.0.0.0 255.255.254.0\n",
+		},
+		{
+			Args:   []string{"merge", "-strict"},
+			Stdin:  "10.0.1.0-10.0.1.255\n10.1.2.3/8\n",
+			Stderr: "cidrman merge: <stdin>:2: CIDR block \"10.1.2.3/8\" has host bits set, expected 10.0.0.0/8\n",
+			Status: 1,
+		},
+		{
+			Args:   []string{"merge"},
+			Stdin:  "10.0.0.0/24\n10.0.1.255-10.0.1.0\n",
+			Stderr: "cidrman merge: <stdin>:2: invalid range \"10.0.1.255-10.0.1.0\"",
+			Status: 1,
+		},
+		{
+			Args:   []string{"merge", "-format", "netmask"},
+			Stdin:  "10.0.0.0/15\n",
+			Stdout: "10.0.0.0 255.254.0.0\n",
+		},
+		{
+			Args:   []string{"merge", "-format", "octal"},
+			Stdin:  "10.0.0.0/15\n",
+			Stderr: "unknown format",
+			Status: 1,
+		},
+		{
+			Args:   []string{"merge"},
+			Stdin:  "10.0.0.0 0.255.0.255\n",
+			Stderr: "<stdin>:1: non-contiguous mask",
+			Status: 1,
+		},
+		{
+			Args:   []string{"merge", filepath.Join(dir, "missing.txt")},
+			Stderr: "missing.txt",
+			Status: 1,
+		},
+		{
+			Args:   []string{"range"},
+			Stdin:  "192.168.1.1 192.168.1.4\n",
+			Stdout: "192.168.1.1/32\n192.168.1.2/31\n192.168.1.4/32\n",
+		},
+		{
+			Args:   []string{"range"},
+			Stdin:  "192.168.1.1-4\n192.168.1.5 - 192.168.1.5\n",
+			Stdout: "192.168.1.1/32\n192.168.1.2/31\n192.168.1.4/32\n192.168.1.5/32\n",
+		},
+		{
+			Args:   []string{"range"},
+			Stdin:  "192.168.1.4 192.168.1.1\n",
+			Stderr: "<stdin>:1: invalid range",
+			Status: 1,
+		},
+		{
+			Args:   []string{"subnets", "-prefix", "26"},
+			Stdin:  "192.0.2.0/25\n",
+			Stdout: "192.0.2.0/26\n192.0.2.64/26\n",
+		},
+		{
+			Args:   []string{"subnets", "-prefix", "128"},
+			Stdin:  "2001:db8::/64\n",
+			Stderr: "cidrman subnets: <stdin>:1: more than 1048576 subnets of length 128 in 2001:db8::/64\n",
+			Status: 1,
+		},
+		{
+			Args:   []string{"subnets"},
+			Stdin:  "192.0.2.0/25\n",
+			Stderr: "missing -prefix",
+			Status: 1,
+		},
+		{
+			Args:   []string{"subnets", "-prefix", "24"},
+			Stdin:  "192.0.2.0/25\n",
+			Stderr: "<stdin>:1: Invalid prefix length 24 for network 192.0.2.0/25, expected 25 to 32",
+			Status: 1,
+		},
+		{
+			Args:   []string{"exclude", "-exclude", cidrsFile},
+			Stdin:  "10.0.0.0/7\n2001:db8::/31\n",
+			Stdout: "11.0.0.0/8\n2001:db9::/32\n",
+		},
+		{
+			Args:   []string{"diff", "-old", cidrsFile},
+			Stdin:  "10.0.0.0/9\n10.128.0.0/9\n192.0.2.0/24\n",
+			Stdout: "--- " + cidrsFile + "\n+++ <stdin>\n 10.0.0.0/8\n+192.0.2.0/24\n-2001:db8::/32\n",
+		},
+		{
+			Args:   []string{"diff"},
+			Stdin:  "10.0.0.0/8\n",
+			Stderr: "missing -old",
+			Status: 1,
+		},
+		{
+			Args:   []string{"contains", "-cidrs", cidrsFile},
+			Stdin:  "10.1.2.3\n192.0.2.1\n2001:db8::1\n",
+			Stdout: "10.1.2.3\n2001:db8::1\n",
+		},
+		{
+			Args:   []string{"contains", "-v", "-cidrs", cidrsFile},
+			Stdin:  "10.1.2.3\n192.0.2.1\n2001:db8::1\n",
+			Stdout: "192.0.2.1\n",
+		},
+		{
+			Args:   []string{"contains", "-cidrs", cidrsFile},
+			Stdin:  "10.1.2.3\nnot-an-address\n",
+			Stdout: "10.1.2.3\n",
+			Stderr: "<stdin>:2: invalid IP address \"not-an-address\"",
+			Status: 1,
+		},
+		{
+			Args:   []string{"info"},
+			Stdin:  "192.0.2.77/24\n",
+			Stdout: "cidr       192.0.2.0/24\nnetwork    192.0.2.0\nbroadcast  192.0.2.255\nnetmask    255.255.255.0\nhostmask   0.0.0.255\nprefix     24\naddresses  256\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		var stdout, stderr bytes.Buffer
+		status := run(testCase.Args, strings.NewReader(testCase.Stdin), &stdout, &stderr)
+		if status != testCase.Status {
+			t.Errorf("run(%#v) expected status: %d, got: %d (%s)", testCase.Args, testCase.Status, status, stderr.String())
+		}
+		if stdout.String() != testCase.Stdout {
+			t.Errorf("run(%#v) expected stdout: %#v, got: %#v", testCase.Args, testCase.Stdout, stdout.String())
+		}
+		if !strings.Contains(stderr.String(), testCase.Stderr) || (testCase.Stderr == "" && stderr.Len() > 0) {
+			t.Errorf("run(%#v) expected stderr: %#v, got: %#v", testCase.Args, testCase.Stderr, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package cidrman
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+)
+
+// CIDRDiff is the change in address space between an old and a new list of CIDR blocks.
+// Each list is the smallest possible list of CIDRs in ascending order, IPv4 before IPv6.
+type CIDRDiff struct {
+	// Added is the address space only in the new list.
+	Added []string
+	// Removed is the address space only in the old list.
+	Removed []string
+	// Unchanged is the address space in both lists.
+	Unchanged []string
+}
+
+// Diff accepts an old and a new list of CIDR blocks and computes the address space added, removed
+// and unchanged, independently of how the address space is split into CIDR blocks in either list.
+func Diff(old, new []string) (*CIDRDiff, error) {
+	oldNets, err := parseCIDRs(old)
+	if err != nil {
+		return nil, err
+	}
+	newNets, err := parseCIDRs(new)
+	if err != nil {
+		return nil, err
+	}
+
+	old4s, old6s := ipNets(oldNets).toBlocks()
+	new4s, new6s := ipNets(newNets).toBlocks()
+
+	added, err := diffCIDRs(remove4(new4s.copy(), old4s.copy()), remove6(new6s.copy(), old6s.copy()))
+	if err != nil {
+		return nil, err
+	}
+	removed, err := diffCIDRs(remove4(old4s.copy(), new4s.copy()), remove6(old6s.copy(), new6s.copy()))
+	if err != nil {
+		return nil, err
+	}
+	unchanged, err := diffCIDRs(intersect4(old4s, new4s), intersect6(old6s, new6s))
+	if err != nil {
+		return nil, err
+	}
+
+	return &CIDRDiff{Added: added, Removed: removed, Unchanged: unchanged}, nil
+}
+
+// diffCIDRs converts the IPv4 and IPv6 blocks to a list of CIDRs, an empty list if there are none.
+func diffCIDRs(block4s cidrBlock4s, block6s cidrBlock6s) ([]string, error) {
+	nets4, err := block4s.toIPNets()
+	if err != nil {
+		return nil, err
+	}
+	nets6, err := block6s.toIPNets()
+	if err != nil {
+		return nil, err
+	}
+
+	cidrs := ipNets(append(nets4, nets6...)).toCIDRs()
+	if cidrs == nil {
+		return make([]string, 0), nil
+	}
+	return cidrs, nil
+}
+
+// diffLine is a CIDR block in the unified rendering of a CIDRDiff.
+type diffLine struct {
+	op      byte
+	network *net.IPNet
+}
+
+// WriteUnified writes the diff in the style of a unified diff: a header naming the old and new lists,
+// followed by all CIDR blocks in ascending order prefixed by '-' if removed, '+' if added or ' ' if unchanged.
+func (d *CIDRDiff) WriteUnified(w io.Writer, oldName, newName string) error {
+	var lines []diffLine
+	for _, list := range []struct {
+		op    byte
+		cidrs []string
+	}{{'-', d.Removed}, {'+', d.Added}, {' ', d.Unchanged}} {
+		for _, cidr := range list.cidrs {
+			_, network, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return &ParseError{Kind: KindCIDR, Input: cidr, Index: -1}
+			}
+			lines = append(lines, diffLine{op: list.op, network: network})
+		}
+	}
+
+	// The lists are disjoint, so the first address orders the blocks.
+	sort.Slice(lines, func(i, j int) bool {
+		a4, b4 := lines[i].network.IP.To4() != nil, lines[j].network.IP.To4() != nil
+		if a4 != b4 {
+			return a4
+		}
+		return bytes.Compare(lines[i].network.IP.To16(), lines[j].network.IP.To16()) < 0
+	})
+
+	if _, err := fmt.Fprintf(w, "--- %s\n+++ %s\n", oldName, newName); err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintf(w, "%c%s\n", line.op, line.network); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
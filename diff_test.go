@@ -0,0 +1,104 @@
+// go test -v -run="TestDiff"
+
+package cidrman
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	type TestCase struct {
+		Old    []string
+		New    []string
+		Output *CIDRDiff
+		Error  bool
+	}
+
+	testCases := []TestCase{
+		{
+			Old:    nil,
+			New:    nil,
+			Output: &CIDRDiff{Added: []string{}, Removed: []string{}, Unchanged: []string{}},
+		},
+		{
+			Old:   []string{"192.0.2.0/24"},
+			New:   []string{"192.0.2.300/24"},
+			Error: true,
+		},
+		{
+			Old: []string{"192.0.2.0/24"},
+			New: []string{"192.0.2.0/25", "192.0.2.128/25"},
+			Output: &CIDRDiff{
+				Added:     []string{},
+				Removed:   []string{},
+				Unchanged: []string{"192.0.2.0/24"},
+			},
+		},
+		{
+			Old: []string{"10.0.0.0/8", "192.0.2.0/24"},
+			New: []string{"10.0.0.0/9", "192.0.2.0/23", "198.51.100.0/24"},
+			Output: &CIDRDiff{
+				Added:     []string{"192.0.3.0/24", "198.51.100.0/24"},
+				Removed:   []string{"10.128.0.0/9"},
+				Unchanged: []string{"10.0.0.0/9", "192.0.2.0/24"},
+			},
+		},
+		{
+			Old: []string{"10.0.0.0/8", "2001:db8::/32"},
+			New: []string{"2001:db8::/33", "2001:db9::/32"},
+			Output: &CIDRDiff{
+				Added:     []string{"2001:db9::/32"},
+				Removed:   []string{"10.0.0.0/8", "2001:db8:8000::/33"},
+				Unchanged: []string{"2001:db8::/33"},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		output, err := Diff(testCase.Old, testCase.New)
+		if err != nil {
+			if !testCase.Error {
+				t.Errorf("Diff(%#v, %#v) failed: %s", testCase.Old, testCase.New, err.Error())
+			}
+			continue
+		}
+		if testCase.Error {
+			t.Errorf("Diff(%#v, %#v) expected error, got: %#v", testCase.Old, testCase.New, output)
+			continue
+		}
+		if !reflect.DeepEqual(testCase.Output, output) {
+			t.Errorf("Diff(%#v, %#v) expected: %#v, got: %#v", testCase.Old, testCase.New, testCase.Output, output)
+		}
+	}
+}
+
+// go test -v -run="TestWriteUnified"
+
+func TestWriteUnified(t *testing.T) {
+	diff, err := Diff(
+		[]string{"10.0.0.0/8", "192.0.2.0/24", "2001:db8::/32"},
+		[]string{"10.0.0.0/9", "192.0.2.0/23", "198.51.100.0/24", "2001:db8::/32"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	if err := diff.WriteUnified(&b, "old.txt", "new.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "--- old.txt\n" +
+		"+++ new.txt\n" +
+		" 10.0.0.0/9\n" +
+		"-10.128.0.0/9\n" +
+		" 192.0.2.0/24\n" +
+		"+192.0.3.0/24\n" +
+		"+198.51.100.0/24\n" +
+		" 2001:db8::/32\n"
+	if b.String() != expected {
+		t.Errorf("WriteUnified() expected: %q, got: %q", expected, b.String())
+	}
+}
@@ -0,0 +1,124 @@
+package cidrman
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrMismatchedFamily is returned when the start and end of an IP range are of different address families.
+var ErrMismatchedFamily = errors.New("Mismatched IP address types")
+
+// ErrRangeReversed is returned when the end of an IP range is before its start.
+var ErrRangeReversed = errors.New("End < Start")
+
+// ErrNonContiguousMask is wrapped by a ParseError for a netmask or wildcard mask whose ones are not contiguous.
+var ErrNonContiguousMask = errors.New("Non-contiguous mask")
+
+// ErrTooManyBlocks is returned when a non-contiguous wildcard mask matches too many CIDR blocks to expand,
+// or a network would be divided into too many subnets.
+var ErrTooManyBlocks = errors.New("Too many CIDR blocks")
+
+// Kinds of input reported by ParseError.
+const (
+	KindCIDR   = "CIDR block"
+	KindIP     = "IP address"
+	KindRange  = "IP range"
+	KindPrefix = "prefix"
+)
+
+// ParseError is returned for input that is not a valid CIDR block, IP address, IP range or prefix.
+type ParseError struct {
+	// Kind is the kind of input expected, one of KindCIDR, KindIP, KindRange or KindPrefix.
+	Kind string
+	// Input is the input as given.
+	Input string
+	// Index is the position of the input in a list, or -1 when the input is not part of a list.
+	Index int
+	// Err is the underlying error, if any.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	msg := fmt.Sprintf("Invalid %s: %s", e.Kind, e.Input)
+	if e.Index >= 0 {
+		msg += fmt.Sprintf(" at index %d", e.Index)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+
+	return msg
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// PrefixLengthError is returned for a prefix length outside the range allowed for a network.
+type PrefixLengthError struct {
+	// Prefix is the prefix length requested.
+	Prefix int
+	// Network is the network the prefix length applies to, if any.
+	Network string
+	// Min and Max are the shortest and longest prefix lengths allowed.
+	Min int
+	Max int
+}
+
+func (e *PrefixLengthError) Error() string {
+	if e.Network != "" {
+		return fmt.Sprintf("Invalid prefix length %d for network %s, expected %d to %d", e.Prefix, e.Network, e.Min, e.Max)
+	}
+	return fmt.Sprintf("Invalid prefix length %d, expected %d to %d", e.Prefix, e.Min, e.Max)
+}
+
+// ErrNoSpace is returned by an Allocator when no free block of the requested size is left.
+var ErrNoSpace = errors.New("No free space")
+
+// ErrNotAvailable is returned by an Allocator when a specific block is outside the pools or already allocated.
+var ErrNotAvailable = errors.New("Not available")
+
+// ErrNotAllocated is returned by an Allocator when releasing a block that is not allocated.
+var ErrNotAllocated = errors.New("Not allocated")
+
+// ErrInvalidFamily is returned for an address family other than IPv4 or IPv6.
+var ErrInvalidFamily = errors.New("Invalid address family")
+
+// ErrDuplicateName is returned by IPAM for an allocation name that is already in use.
+var ErrDuplicateName = errors.New("Duplicate allocation name")
+
+// ErrUnknownName is returned by IPAM for an allocation name that is not in use.
+var ErrUnknownName = errors.New("Unknown allocation name")
+
+// ErrOverlap is returned by IPAM for an allocation overlapping another allocation.
+var ErrOverlap = errors.New("Overlaps another allocation")
+
+// ErrOutsidePools is returned by IPAM for an allocation not entirely within the pools.
+var ErrOutsidePools = errors.New("Outside the pools")
+
+// ErrUnsupportedVersion is returned by IPAM when loading state saved with a version it does not support.
+var ErrUnsupportedVersion = errors.New("Unsupported IPAM state version")
+
+// AllocationError is returned by IPAM for a named allocation that cannot be made, released or loaded.
+type AllocationError struct {
+	// Name is the name of the allocation.
+	Name string
+	// CIDR is the CIDR block of the allocation, if known.
+	CIDR string
+	// Err is the reason, such as ErrDuplicateName, ErrOverlap or ErrNoSpace.
+	Err error
+}
+
+func (e *AllocationError) Error() string {
+	if e.CIDR != "" {
+		return fmt.Sprintf("Allocation %s (%s): %s", e.Name, e.CIDR, e.Err)
+	}
+	return fmt.Sprintf("Allocation %s: %s", e.Name, e.Err)
+}
+
+func (e *AllocationError) Unwrap() error {
+	return e.Err
+}
+
+// ErrTooFewEntries is returned by Summarize when the entries allowed cannot cover the address families present.
+var ErrTooFewEntries = errors.New("Too few entries")
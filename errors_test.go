@@ -0,0 +1,145 @@
+// go test -v -run="TestErrors"
+
+package cidrman
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	type TestCase struct {
+		Name   string
+		Call   func() error
+		Is     error
+		Parse  *ParseError
+		Prefix *PrefixLengthError
+	}
+
+	testCases := []TestCase{
+		{
+			Name: "MergeCIDRs",
+			Call: func() error {
+				_, err := MergeCIDRs([]string{"10.0.0.0/8", "10.0.0.0/33"})
+				return err
+			},
+			Parse: &ParseError{Kind: KindCIDR, Input: "10.0.0.0/33", Index: 1},
+		},
+		{
+			Name: "RemoveCIDRs",
+			Call: func() error {
+				_, err := RemoveCIDRs([]string{"10.0.0.0/8"}, []string{"abcdefgh"})
+				return err
+			},
+			Parse: &ParseError{Kind: KindCIDR, Input: "abcdefgh", Index: 0},
+		},
+		{
+			Name: "MergeCIDRsWithOptions",
+			Call: func() error {
+				_, _, err := MergeCIDRsWithOptions([]string{"10.0.0.0/8", "10.0.0.0/8", "bad"}, Options{})
+				return err
+			},
+			Parse: &ParseError{Kind: KindCIDR, Input: "bad", Index: 2},
+		},
+		{
+			Name: "IPRangeToCIDRs invalid",
+			Call: func() error {
+				_, err := IPRangeToCIDRs("192.0.2.1", "192.0.2.300")
+				return err
+			},
+			Parse: &ParseError{Kind: KindIP, Input: "192.0.2.300", Index: -1},
+		},
+		{
+			Name: "IPRangeToCIDRs reversed",
+			Call: func() error {
+				_, err := IPRangeToCIDRs("192.0.2.2", "192.0.2.1")
+				return err
+			},
+			Is: ErrRangeReversed,
+		},
+		{
+			Name: "IPRangeToIPNets mismatched",
+			Call: func() error {
+				_, err := IPRangeToIPNets(net.ParseIP("192.0.2.1"), net.ParseIP("2001:db8::1"))
+				return err
+			},
+			Is: ErrMismatchedFamily,
+		},
+		{
+			Name: "RangeToPrefixes reversed",
+			Call: func() error {
+				_, err := RangeToPrefixes(netip.MustParseAddr("2001:db8::2"), netip.MustParseAddr("2001:db8::1"))
+				return err
+			},
+			Is: ErrRangeReversed,
+		},
+		{
+			Name: "ParseRange reversed",
+			Call: func() error {
+				_, _, err := ParseRange("192.0.2.10-1")
+				return err
+			},
+			Is:    ErrRangeReversed,
+			Parse: &ParseError{Kind: KindRange, Input: "192.0.2.10-1", Index: -1, Err: ErrRangeReversed},
+		},
+		{
+			Name: "MergeMixed mismatched",
+			Call: func() error {
+				_, err := MergeMixed([]string{"192.0.2.1", "192.0.2.1-2001:db8::1"})
+				return err
+			},
+			Is:    ErrMismatchedFamily,
+			Parse: &ParseError{Kind: KindRange, Input: "192.0.2.1-2001:db8::1", Index: 1, Err: ErrMismatchedFamily},
+		},
+		{
+			Name: "Subnets",
+			Call: func() error {
+				_, err := Subnets("192.0.2.0/24", 23)
+				return err
+			},
+			Prefix: &PrefixLengthError{Prefix: 23, Network: "192.0.2.0/24", Min: 24, Max: 32},
+		},
+		{
+			Name: "SubnetsPrefix",
+			Call: func() error {
+				_, err := SubnetsPrefix(netip.MustParsePrefix("2001:db8::/32"), 129)
+				return err
+			},
+			Prefix: &PrefixLengthError{Prefix: 129, Network: "2001:db8::/32", Min: 32, Max: 128},
+		},
+		{
+			Name: "MergePrefixes",
+			Call: func() error {
+				_, err := MergePrefixes([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), {}})
+				return err
+			},
+			Parse: &ParseError{Kind: KindPrefix, Input: "invalid Prefix", Index: 1},
+		},
+	}
+
+	for _, testCase := range testCases {
+		err := testCase.Call()
+		if err == nil {
+			t.Errorf("%s expected error", testCase.Name)
+			continue
+		}
+		if testCase.Is != nil && !errors.Is(err, testCase.Is) {
+			t.Errorf("%s expected errors.Is(%v), got: %v", testCase.Name, testCase.Is, err)
+		}
+		if testCase.Parse != nil {
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) || !reflect.DeepEqual(testCase.Parse, parseErr) {
+				t.Errorf("%s expected: %#v, got: %#v", testCase.Name, testCase.Parse, err)
+			}
+		}
+		if testCase.Prefix != nil {
+			var prefixErr *PrefixLengthError
+			if !errors.As(err, &prefixErr) || !reflect.DeepEqual(testCase.Prefix, prefixErr) {
+				t.Errorf("%s expected: %#v, got: %#v", testCase.Name, testCase.Prefix, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package cidrman
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// IPAMVersion is the version of the IPAM state file format written by IPAM.
+const IPAMVersion = 1
+
+// Metadata describes who an allocation is for.
+type Metadata struct {
+	Owner string `json:"owner,omitempty"`
+	Tag   string `json:"tag,omitempty"`
+}
+
+// Allocation is a named CIDR block allocated from the IPAM pools.
+type Allocation struct {
+	Name string `json:"name"`
+	CIDR string `json:"cidr"`
+	Metadata
+	Created time.Time `json:"created"`
+}
+
+// ipamState is the serialised form of an IPAM.
+type ipamState struct {
+	Version     int          `json:"version"`
+	Pools       []string     `json:"pools"`
+	Allocations []Allocation `json:"allocations"`
+}
+
+// IPAM manages named allocations out of pools of IPv4 and IPv6 address space.
+// Its state serialises to and from versioned JSON, so it can be kept in a file under version control.
+// It is safe for concurrent use.
+type IPAM struct {
+	mu          sync.Mutex
+	pools       []string
+	allocator   *Allocator
+	allocations map[string]Allocation
+	now         func() time.Time
+}
+
+// NewIPAM returns an IPAM without allocations managing the pool CIDR blocks.
+func NewIPAM(pools []string) (*IPAM, error) {
+	merged, err := MergeCIDRs(pools)
+	if err != nil {
+		return nil, err
+	}
+	allocator, err := NewAllocator(merged)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IPAM{
+		pools:       merged,
+		allocator:   allocator,
+		allocations: make(map[string]Allocation),
+		now:         time.Now,
+	}, nil
+}
+
+// add records an allocation of a CIDR block already taken from the allocator.
+func (p *IPAM) add(name, cidr string, meta Metadata, created time.Time) Allocation {
+	allocation := Allocation{Name: name, CIDR: cidr, Metadata: meta, Created: created}
+	p.allocations[name] = allocation
+	return allocation
+}
+
+// Allocate allocates a free subnet of the prefix length from the pools of the address family under the name.
+func (p *IPAM) Allocate(name string, family Family, prefixLen int, meta Metadata) (Allocation, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.allocations[name]; ok {
+		return Allocation{}, &AllocationError{Name: name, Err: ErrDuplicateName}
+	}
+
+	cidr, err := p.allocator.Allocate(family, prefixLen)
+	if err != nil {
+		return Allocation{}, &AllocationError{Name: name, Err: err}
+	}
+
+	return p.add(name, cidr, meta, p.now().UTC()), nil
+}
+
+// AllocateSpecific allocates the CIDR block under the name. It must be free and within the pools, and
+// a CIDR block with host bits set, such as 10.0.0.77/26, is rejected with a *HostBitsError.
+func (p *IPAM) AllocateSpecific(name, cidr string, meta Metadata) (Allocation, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.allocations[name]; ok {
+		return Allocation{}, &AllocationError{Name: name, CIDR: cidr, Err: ErrDuplicateName}
+	}
+
+	return p.allocateSpecific(name, cidr, -1, meta, p.now().UTC())
+}
+
+// allocateSpecific takes the CIDR block from the allocator, telling apart overlaps from blocks outside the pools.
+// Errors report the index of the allocation in the saved state, or -1.
+func (p *IPAM) allocateSpecific(name, cidr string, index int, meta Metadata, created time.Time) (Allocation, error) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return Allocation{}, &AllocationError{Name: name, CIDR: cidr, Err: &ParseError{Kind: KindCIDR, Input: cidr, Index: index}}
+	}
+	if masked := prefix.Masked(); masked != prefix {
+		return Allocation{}, &AllocationError{Name: name, CIDR: cidr, Err: &HostBitsError{Index: index, Input: cidr, Network: masked.String()}}
+	}
+	cidr = prefix.String()
+
+	if err := p.allocator.AllocateSpecific(cidr); err != nil {
+		if errors.Is(err, ErrNotAvailable) {
+			pools, _ := NewIPSet(p.pools)
+			block, _ := NewIPSet([]string{cidr})
+			if block.IsSubsetOf(pools) {
+				err = ErrOverlap
+			} else {
+				err = ErrOutsidePools
+			}
+		}
+		return Allocation{}, &AllocationError{Name: name, CIDR: cidr, Err: err}
+	}
+
+	return p.add(name, cidr, meta, created), nil
+}
+
+// Release releases the allocation with the name, returning its CIDR block to the free space.
+func (p *IPAM) Release(name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	allocation, ok := p.allocations[name]
+	if !ok {
+		return &AllocationError{Name: name, Err: ErrUnknownName}
+	}
+	if err := p.allocator.Release(allocation.CIDR); err != nil {
+		return &AllocationError{Name: name, CIDR: allocation.CIDR, Err: err}
+	}
+	delete(p.allocations, name)
+
+	return nil
+}
+
+// Get returns the allocation with the name.
+func (p *IPAM) Get(name string) (Allocation, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	allocation, ok := p.allocations[name]
+	return allocation, ok
+}
+
+// Allocations returns the allocations in ascending address order, IPv4 allocations first.
+func (p *IPAM) Allocations() []Allocation {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.sortedAllocations()
+}
+
+// sortedAllocations returns the allocations in ascending address order, IPv4 allocations first.
+func (p *IPAM) sortedAllocations() []Allocation {
+	allocations := make([]Allocation, 0, len(p.allocations))
+	for _, allocation := range p.allocations {
+		allocations = append(allocations, allocation)
+	}
+
+	sort.Slice(allocations, func(i, j int) bool {
+		lhs := netip.MustParsePrefix(allocations[i].CIDR)
+		rhs := netip.MustParsePrefix(allocations[j].CIDR)
+		if cmp := lhs.Addr().Compare(rhs.Addr()); cmp != 0 {
+			return cmp < 0
+		}
+		return lhs.Bits() < rhs.Bits()
+	})
+
+	return allocations
+}
+
+// Pools returns the merged pool CIDR blocks.
+func (p *IPAM) Pools() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]string(nil), p.pools...)
+}
+
+// Free returns the smallest possible list of CIDRs covering the unallocated space, IPv4 blocks first.
+func (p *IPAM) Free() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.allocator.Free()
+}
+
+// Used returns the smallest possible list of CIDRs covering the allocated space, IPv4 blocks first.
+func (p *IPAM) Used() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.allocator.Used()
+}
+
+// MarshalJSON encodes the pools and allocations as versioned JSON.
+func (p *IPAM) MarshalJSON() ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return json.Marshal(ipamState{Version: IPAMVersion, Pools: p.pools, Allocations: p.sortedAllocations()})
+}
+
+// UnmarshalJSON decodes the pools and allocations from versioned JSON, replacing the current state.
+// It fails with ErrUnsupportedVersion if the version is not supported, or if an allocation is outside the pools,
+// overlaps another or has host bits set.
+func (p *IPAM) UnmarshalJSON(data []byte) error {
+	var state ipamState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	if state.Version != IPAMVersion {
+		return fmt.Errorf("%w %d, expected %d", ErrUnsupportedVersion, state.Version, IPAMVersion)
+	}
+
+	loaded, err := NewIPAM(state.Pools)
+	if err != nil {
+		return err
+	}
+	for i, allocation := range state.Allocations {
+		if _, ok := loaded.allocations[allocation.Name]; ok {
+			return &AllocationError{Name: allocation.Name, CIDR: allocation.CIDR, Err: ErrDuplicateName}
+		}
+		if _, err := loaded.allocateSpecific(allocation.Name, allocation.CIDR, i, allocation.Metadata, allocation.Created); err != nil {
+			return err
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.pools = loaded.pools
+	p.allocator = loaded.allocator
+	p.allocations = loaded.allocations
+	if p.now == nil {
+		p.now = time.Now
+	}
+	return nil
+}
+
+// LoadIPAM reads an IPAM from a JSON state file.
+func LoadIPAM(path string) (*IPAM, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var p IPAM
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Save writes the IPAM to a JSON state file. The file is replaced atomically.
+func (p *IPAM) Save(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
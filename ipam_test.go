@@ -0,0 +1,177 @@
+// go test -v -run="TestIPAM"
+
+package cidrman
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIPAM(t *testing.T) {
+	p, err := NewIPAM([]string{"10.0.0.0/25", "10.0.0.128/25", "2001:db8::/48"})
+	if err != nil {
+		t.Fatalf("NewIPAM failed: %s", err.Error())
+	}
+	created := time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return created }
+
+	if pools := p.Pools(); !reflect.DeepEqual([]string{"10.0.0.0/24", "2001:db8::/48"}, pools) {
+		t.Errorf("Pools expected merged pools, got: %#v", pools)
+	}
+
+	web, err := p.Allocate("web", IPv4, 26, Metadata{Owner: "alice", Tag: "prod"})
+	if err != nil {
+		t.Fatalf("Allocate(web) failed: %s", err.Error())
+	}
+	expected := Allocation{Name: "web", CIDR: "10.0.0.0/26", Metadata: Metadata{Owner: "alice", Tag: "prod"}, Created: created}
+	if !reflect.DeepEqual(expected, web) {
+		t.Errorf("Allocate(web) expected: %#v, got: %#v", expected, web)
+	}
+
+	if _, err := p.AllocateSpecific("db", "2001:db8:0:1::/64", Metadata{Owner: "bob"}); err != nil {
+		t.Fatalf("AllocateSpecific(db) failed: %s", err.Error())
+	}
+	if db, _ := p.Get("db"); db.CIDR != "2001:db8:0:1::/64" {
+		t.Errorf("Get(db) expected: %#v, got: %#v", "2001:db8:0:1::/64", db.CIDR)
+	}
+
+	// Host bits are rejected rather than masked, so typos are not silently rewritten.
+	_, err = p.AllocateSpecific("typo", "10.0.0.77/26", Metadata{})
+	expectedErr := &AllocationError{Name: "typo", CIDR: "10.0.0.77/26", Err: &HostBitsError{Index: -1, Input: "10.0.0.77/26", Network: "10.0.0.64/26"}}
+	if !reflect.DeepEqual(expectedErr, err) {
+		t.Errorf("AllocateSpecific(typo) expected error: %#v, got: %#v", expectedErr, err)
+	}
+	if _, ok := p.Get("typo"); ok {
+		t.Errorf("Get(typo) expected no allocation")
+	}
+
+	type TestCase struct {
+		Name string
+		Call func() error
+		Err  error
+	}
+
+	testCases := []TestCase{
+		{
+			Name: "Allocate duplicate name",
+			Call: func() error { _, err := p.Allocate("web", IPv4, 26, Metadata{}); return err },
+			Err:  ErrDuplicateName,
+		},
+		{
+			Name: "Allocate no space",
+			Call: func() error { _, err := p.Allocate("big", IPv4, 24, Metadata{}); return err },
+			Err:  ErrNoSpace,
+		},
+		{
+			Name: "AllocateSpecific overlap",
+			Call: func() error { _, err := p.AllocateSpecific("overlap", "10.0.0.32/27", Metadata{}); return err },
+			Err:  ErrOverlap,
+		},
+		{
+			Name: "AllocateSpecific outside pools",
+			Call: func() error { _, err := p.AllocateSpecific("outside", "10.0.1.0/27", Metadata{}); return err },
+			Err:  ErrOutsidePools,
+		},
+		{
+			Name: "Release unknown",
+			Call: func() error { return p.Release("unknown") },
+			Err:  ErrUnknownName,
+		},
+	}
+
+	for _, testCase := range testCases {
+		err := testCase.Call()
+		var allocationErr *AllocationError
+		if !errors.Is(err, testCase.Err) || !errors.As(err, &allocationErr) {
+			t.Errorf("%s expected error: %v, got: %v", testCase.Name, testCase.Err, err)
+		}
+	}
+
+	// Save and load the state through a file.
+	path := filepath.Join(t.TempDir(), "ipam.json")
+	if err := p.Save(path); err != nil {
+		t.Fatalf("Save failed: %s", err.Error())
+	}
+	loaded, err := LoadIPAM(path)
+	if err != nil {
+		t.Fatalf("LoadIPAM failed: %s", err.Error())
+	}
+	if !reflect.DeepEqual(p.Allocations(), loaded.Allocations()) {
+		t.Errorf("LoadIPAM expected allocations: %#v, got: %#v", p.Allocations(), loaded.Allocations())
+	}
+	if !reflect.DeepEqual(p.Free(), loaded.Free()) {
+		t.Errorf("LoadIPAM expected free: %#v, got: %#v", p.Free(), loaded.Free())
+	}
+
+	// Released space is available again.
+	if err := loaded.Release("web"); err != nil {
+		t.Fatalf("Release(web) failed: %s", err.Error())
+	}
+	if free := loaded.Free(); !reflect.DeepEqual("10.0.0.0/24", free[0]) {
+		t.Errorf("Free after Release expected: %#v, got: %#v", "10.0.0.0/24", free[0])
+	}
+}
+
+func TestIPAMUnmarshalJSON(t *testing.T) {
+	var p IPAM
+	err := json.Unmarshal([]byte(`{"version": 2, "pools": [], "allocations": []}`), &p)
+	if expected := "Unsupported IPAM state version 2, expected 1"; err == nil || err.Error() != expected {
+		t.Errorf("Unmarshal() expected error: %s, got: %v", expected, err)
+	}
+
+	// An allocation with host bits set is rejected, reporting its index in the state.
+	err = json.Unmarshal([]byte(`{"version": 1, "pools": ["10.0.0.0/24"], "allocations": [{"name": "a", "cidr": "10.0.0.0/26"}, {"name": "b", "cidr": "10.0.0.77/26"}]}`), &p)
+	expectedErr := &AllocationError{Name: "b", CIDR: "10.0.0.77/26", Err: &HostBitsError{Index: 1, Input: "10.0.0.77/26", Network: "10.0.0.64/26"}}
+	if !reflect.DeepEqual(expectedErr, err) {
+		t.Errorf("Unmarshal() expected error: %#v, got: %#v", expectedErr, err)
+	}
+
+	type TestCase struct {
+		Input string
+		Err   error
+	}
+
+	testCases := []TestCase{
+		{
+			Input: `{"version": 1, "pools": ["10.0.0.0/24"], "allocations": [{"name": "a", "cidr": "10.0.0.0/25", "created": "2022-04-01T12:00:00Z"}]}`,
+			Err:   nil,
+		},
+		{
+			Input: `{"version": 2, "pools": ["10.0.0.0/24"], "allocations": []}`,
+			Err:   ErrUnsupportedVersion,
+		},
+		{
+			Input: `{"pools": ["10.0.0.0/24"], "allocations": []}`,
+			Err:   ErrUnsupportedVersion,
+		},
+		{
+			Input: `{"version": 1, "pools": ["10.0.0.0/24"], "allocations": [{"name": "a", "cidr": "10.0.0.0/25"}, {"name": "b", "cidr": "10.0.0.64/26"}]}`,
+			Err:   ErrOverlap,
+		},
+		{
+			Input: `{"version": 1, "pools": ["10.0.0.0/24"], "allocations": [{"name": "a", "cidr": "10.0.0.0/23"}]}`,
+			Err:   ErrOutsidePools,
+		},
+		{
+			Input: `{"version": 1, "pools": ["10.0.0.0/24"], "allocations": [{"name": "a", "cidr": "10.0.0.0/26"}, {"name": "a", "cidr": "10.0.0.64/26"}]}`,
+			Err:   ErrDuplicateName,
+		},
+	}
+
+	for _, testCase := range testCases {
+		var p IPAM
+		err := json.Unmarshal([]byte(testCase.Input), &p)
+		switch {
+		case testCase.Err == nil && err != nil:
+			t.Errorf("Unmarshal(%s) failed: %s", testCase.Input, err.Error())
+		case testCase.Err != nil && err == nil:
+			t.Errorf("Unmarshal(%s) expected error: %v", testCase.Input, testCase.Err)
+		case testCase.Err != nil && !errors.Is(err, testCase.Err):
+			t.Errorf("Unmarshal(%s) expected error: %v, got: %v", testCase.Input, testCase.Err, err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package cidrman
+
+import (
+	"net"
+)
+
+// IPSet is an immutable set of IPv4 and IPv6 addresses.
+// It is stored as sorted lists of coalesced address ranges, one per address family,
+// so set operations never need to re-parse or re-merge CIDR blocks.
+// The zero value is an empty set.
+type IPSet struct {
+	block4s cidrBlock4s
+	block6s cidrBlock6s
+}
+
+// NewIPSet returns the set of addresses covered by a list of CIDR blocks.
+func NewIPSet(cidrs []string) (*IPSet, error) {
+	networks, err := parseCIDRs(cidrs)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewIPSetFromIPNets(networks), nil
+}
+
+// NewIPSetFromIPNets returns the set of addresses covered by a list of IP networks.
+func NewIPSetFromIPNets(nets []*net.IPNet) *IPSet {
+	block4s, block6s := ipNets(nets).toBlocks()
+
+	return &IPSet{block4s: coalesce4(block4s), block6s: coalesce6(block6s)}
+}
+
+// Union returns the set of addresses in either s or other.
+func (s *IPSet) Union(other *IPSet) *IPSet {
+	return &IPSet{
+		block4s: coalesce4(append(s.block4s.copy(), other.block4s.copy()...)),
+		block6s: coalesce6(append(s.block6s.copy(), other.block6s.copy()...)),
+	}
+}
+
+// Intersect returns the set of addresses in both s and other.
+func (s *IPSet) Intersect(other *IPSet) *IPSet {
+	return &IPSet{
+		block4s: intersect4(s.block4s.copy(), other.block4s.copy()),
+		block6s: intersect6(s.block6s.copy(), other.block6s.copy()),
+	}
+}
+
+// Difference returns the set of addresses in s but not in other.
+func (s *IPSet) Difference(other *IPSet) *IPSet {
+	return &IPSet{
+		block4s: remove4(s.block4s.copy(), other.block4s.copy()),
+		block6s: remove6(s.block6s.copy(), other.block6s.copy()),
+	}
+}
+
+// Complement returns the set of addresses not in s.
+// The complement is taken over both the entire IPv4 and the entire IPv6 address space,
+// so the complement of an IPv4 only set contains all of the IPv6 address space.
+func (s *IPSet) Complement() *IPSet {
+	all4 := cidrBlock4s{&cidrBlock4{first: 0, last: maxUInt32}}
+	all6 := cidrBlock6s{&cidrBlock6{first: uint128{}, last: maxUInt128}}
+
+	return &IPSet{
+		block4s: remove4(all4, s.block4s.copy()),
+		block6s: remove6(all6, s.block6s.copy()),
+	}
+}
+
+// Equal reports whether s and other contain exactly the same addresses.
+func (s *IPSet) Equal(other *IPSet) bool {
+	return s.block4s.equal(other.block4s) && s.block6s.equal(other.block6s)
+}
+
+// IsSubsetOf reports whether every address in s is also in other.
+func (s *IPSet) IsSubsetOf(other *IPSet) bool {
+	difference := s.Difference(other)
+	return len(difference.block4s) == 0 && len(difference.block6s) == 0
+}
+
+// IPNets returns the smallest possible list of IPNets covering the set, IPv4 networks first.
+func (s *IPSet) IPNets() []*net.IPNet {
+	// The blocks are within the address space, so splitting them cannot fail.
+	nets4, _ := s.block4s.toIPNets()
+	nets6, _ := s.block6s.toIPNets()
+
+	nets := append(nets4, nets6...)
+	if nets == nil {
+		return make([]*net.IPNet, 0)
+	}
+	return nets
+}
+
+// CIDRs returns the smallest possible list of CIDRs covering the set, IPv4 blocks first.
+func (s *IPSet) CIDRs() []string {
+	cidrs := ipNets(s.IPNets()).toCIDRs()
+	if cidrs == nil {
+		return make([]string, 0)
+	}
+	return cidrs
+}
@@ -0,0 +1,234 @@
+// go test -v -run="TestIPSet"
+
+package cidrman
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIPSetOperations(t *testing.T) {
+	type TestCase struct {
+		A          []string
+		B          []string
+		Union      []string
+		Intersect  []string
+		Difference []string
+		Equal      bool
+		IsSubsetOf bool
+	}
+
+	testCases := []TestCase{
+		{
+			A:          nil,
+			B:          nil,
+			Union:      []string{},
+			Intersect:  []string{},
+			Difference: []string{},
+			Equal:      true,
+			IsSubsetOf: true,
+		},
+		{
+			A: []string{"192.0.2.0/24"},
+			B: nil,
+			Union: []string{
+				"192.0.2.0/24",
+			},
+			Intersect: []string{},
+			Difference: []string{
+				"192.0.2.0/24",
+			},
+			Equal:      false,
+			IsSubsetOf: false,
+		},
+		{
+			A: []string{"192.0.2.0/25", "192.0.2.128/25"},
+			B: []string{"192.0.2.0/24"},
+			Union: []string{
+				"192.0.2.0/24",
+			},
+			Intersect: []string{
+				"192.0.2.0/24",
+			},
+			Difference: []string{},
+			Equal:      true,
+			IsSubsetOf: true,
+		},
+		{
+			A: []string{"192.0.2.0/25"},
+			B: []string{"192.0.2.0/24"},
+			Union: []string{
+				"192.0.2.0/24",
+			},
+			Intersect: []string{
+				"192.0.2.0/25",
+			},
+			Difference: []string{},
+			Equal:      false,
+			IsSubsetOf: true,
+		},
+		{
+			A: []string{"10.0.0.0/8", "192.0.2.0/24"},
+			B: []string{"10.128.0.0/9", "192.0.2.64/26", "198.51.100.0/24"},
+			Union: []string{
+				"10.0.0.0/8",
+				"192.0.2.0/24",
+				"198.51.100.0/24",
+			},
+			Intersect: []string{
+				"10.128.0.0/9",
+				"192.0.2.64/26",
+			},
+			Difference: []string{
+				"10.0.0.0/9",
+				"192.0.2.0/26",
+				"192.0.2.128/25",
+			},
+			Equal:      false,
+			IsSubsetOf: false,
+		},
+		{
+			A: []string{"2001:db8::/32", "192.0.2.0/24"},
+			B: []string{"2001:db8:8000::/33", "fd00::/8"},
+			Union: []string{
+				"192.0.2.0/24",
+				"2001:db8::/32",
+				"fd00::/8",
+			},
+			Intersect: []string{
+				"2001:db8:8000::/33",
+			},
+			Difference: []string{
+				"192.0.2.0/24",
+				"2001:db8::/33",
+			},
+			Equal:      false,
+			IsSubsetOf: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		a, err := NewIPSet(testCase.A)
+		if err != nil {
+			t.Errorf("NewIPSet(%#v) failed: %s", testCase.A, err.Error())
+			continue
+		}
+		b, err := NewIPSet(testCase.B)
+		if err != nil {
+			t.Errorf("NewIPSet(%#v) failed: %s", testCase.B, err.Error())
+			continue
+		}
+
+		if union := a.Union(b).CIDRs(); !reflect.DeepEqual(testCase.Union, union) {
+			t.Errorf("Union(%#v, %#v) expected: %#v, got: %#v", testCase.A, testCase.B, testCase.Union, union)
+		}
+		if intersect := a.Intersect(b).CIDRs(); !reflect.DeepEqual(testCase.Intersect, intersect) {
+			t.Errorf("Intersect(%#v, %#v) expected: %#v, got: %#v", testCase.A, testCase.B, testCase.Intersect, intersect)
+		}
+		if difference := a.Difference(b).CIDRs(); !reflect.DeepEqual(testCase.Difference, difference) {
+			t.Errorf("Difference(%#v, %#v) expected: %#v, got: %#v", testCase.A, testCase.B, testCase.Difference, difference)
+		}
+		if equal := a.Equal(b); equal != testCase.Equal {
+			t.Errorf("Equal(%#v, %#v) expected: %v, got: %v", testCase.A, testCase.B, testCase.Equal, equal)
+		}
+		if subset := a.IsSubsetOf(b); subset != testCase.IsSubsetOf {
+			t.Errorf("IsSubsetOf(%#v, %#v) expected: %v, got: %v", testCase.A, testCase.B, testCase.IsSubsetOf, subset)
+		}
+
+		// The operations must not modify their operands.
+		if cidrs, _ := MergeCIDRs(testCase.A); len(cidrs) > 0 && !reflect.DeepEqual(cidrs, a.CIDRs()) {
+			t.Errorf("IPSet(%#v) modified: %#v", testCase.A, a.CIDRs())
+		}
+	}
+}
+
+func TestIPSetComplement(t *testing.T) {
+	type TestCase struct {
+		Input  []string
+		Output []string
+	}
+
+	testCases := []TestCase{
+		{
+			Input: nil,
+			Output: []string{
+				"0.0.0.0/0",
+				"::/0",
+			},
+		},
+		{
+			Input: []string{
+				"0.0.0.0/0",
+				"::/0",
+			},
+			Output: []string{},
+		},
+		{
+			Input: []string{
+				"128.0.0.0/1",
+				"::/1",
+			},
+			Output: []string{
+				"0.0.0.0/1",
+				"8000::/1",
+			},
+		},
+		{
+			Input: []string{
+				"0.0.0.0/2",
+				"255.255.255.255/32",
+			},
+			Output: []string{
+				"64.0.0.0/2",
+				"128.0.0.0/2",
+				"192.0.0.0/3",
+				"224.0.0.0/4",
+				"240.0.0.0/5",
+				"248.0.0.0/6",
+				"252.0.0.0/7",
+				"254.0.0.0/8",
+				"255.0.0.0/9",
+				"255.128.0.0/10",
+				"255.192.0.0/11",
+				"255.224.0.0/12",
+				"255.240.0.0/13",
+				"255.248.0.0/14",
+				"255.252.0.0/15",
+				"255.254.0.0/16",
+				"255.255.0.0/17",
+				"255.255.128.0/18",
+				"255.255.192.0/19",
+				"255.255.224.0/20",
+				"255.255.240.0/21",
+				"255.255.248.0/22",
+				"255.255.252.0/23",
+				"255.255.254.0/24",
+				"255.255.255.0/25",
+				"255.255.255.128/26",
+				"255.255.255.192/27",
+				"255.255.255.224/28",
+				"255.255.255.240/29",
+				"255.255.255.248/30",
+				"255.255.255.252/31",
+				"255.255.255.254/32",
+				"::/0",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		set, err := NewIPSet(testCase.Input)
+		if err != nil {
+			t.Errorf("NewIPSet(%#v) failed: %s", testCase.Input, err.Error())
+			continue
+		}
+
+		complement := set.Complement()
+		if output := complement.CIDRs(); !reflect.DeepEqual(testCase.Output, output) {
+			t.Errorf("Complement(%#v) expected: %#v, got: %#v", testCase.Input, testCase.Output, output)
+		}
+		if !complement.Complement().Equal(set) {
+			t.Errorf("Complement(Complement(%#v)) got: %#v", testCase.Input, complement.Complement().CIDRs())
+		}
+	}
+}
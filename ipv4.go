@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"net/netip"
 	"sort"
 )
 
@@ -24,6 +25,19 @@ func uint32ToIPV4(addr uint32) net.IP {
 	return ip
 }
 
+// addr4ToUInt32 converts an IPv4 netip address to an unsigned 32-bit integer.
+func addr4ToUInt32(addr netip.Addr) uint32 {
+	b := addr.As4()
+	return binary.BigEndian.Uint32(b[:])
+}
+
+// uint32ToAddr4 converts an unsigned 32-bit integer to an IPv4 netip address.
+func uint32ToAddr4(addr uint32) netip.Addr {
+	var b [net.IPv4len]byte
+	binary.BigEndian.PutUint32(b[:], addr)
+	return netip.AddrFrom4(b)
+}
+
 // The following functions are inspired by http://www.cs.colostate.edu/~somlo/iprange.c.
 
 // setBit sets the specified bit in an address to 0 or 1.
@@ -64,10 +78,29 @@ func network4(addr uint32, prefix uint) uint32 {
 	return addr & netmask4(prefix)
 }
 
+// emit4 receives each of the IPv4 CIDR blocks computed by splitRange4.
+type emit4 func(addr uint32, prefix uint)
+
+// appendIPNet4 returns an emit4 that appends the CIDR blocks to a list of IPNets.
+func appendIPNet4(cidrs *[]*net.IPNet) emit4 {
+	return func(addr uint32, prefix uint) {
+		cidr := net.IPNet{IP: uint32ToIPV4(addr), Mask: net.CIDRMask(int(prefix), 8*net.IPv4len)}
+		*cidrs = append(*cidrs, &cidr)
+	}
+}
+
+// appendPrefix4 returns an emit4 that appends the CIDR blocks to a list of prefixes.
+func appendPrefix4(prefixes *[]netip.Prefix) emit4 {
+	return func(addr uint32, prefix uint) {
+		*prefixes = append(*prefixes, netip.PrefixFrom(uint32ToAddr4(addr), int(prefix)))
+	}
+}
+
 // splitRange4 recursively computes the CIDR blocks to cover the range lo to hi.
-func splitRange4(addr uint32, prefix uint, lo, hi uint32, cidrs *[]*net.IPNet) error {
+// Blocks shorter than minPrefix are split further instead of being aggregated.
+func splitRange4(addr uint32, prefix uint, lo, hi uint32, minPrefix uint, emit emit4) error {
 	if prefix > widthUInt32 {
-		return fmt.Errorf("Invalid mask size: %d", prefix)
+		return &PrefixLengthError{Prefix: int(prefix), Min: 0, Max: widthUInt32}
 	}
 
 	bc := broadcast4(addr, prefix)
@@ -75,9 +108,8 @@ func splitRange4(addr uint32, prefix uint, lo, hi uint32, cidrs *[]*net.IPNet) e
 		return fmt.Errorf("%d, %d out of range for network %d/%d, broadcast %d", lo, hi, addr, prefix, bc)
 	}
 
-	if (lo == addr) && (hi == bc) {
-		cidr := net.IPNet{IP: uint32ToIPV4(addr), Mask: net.CIDRMask(int(prefix), 8*net.IPv4len)}
-		*cidrs = append(*cidrs, &cidr)
+	if (lo == addr) && (hi == bc) && (prefix >= minPrefix) {
+		emit(addr, prefix)
 		return nil
 	}
 
@@ -85,15 +117,15 @@ func splitRange4(addr uint32, prefix uint, lo, hi uint32, cidrs *[]*net.IPNet) e
 	lowerHalf := addr
 	upperHalf := setBit(addr, prefix, 1)
 	if hi < upperHalf {
-		return splitRange4(lowerHalf, prefix, lo, hi, cidrs)
+		return splitRange4(lowerHalf, prefix, lo, hi, minPrefix, emit)
 	} else if lo >= upperHalf {
-		return splitRange4(upperHalf, prefix, lo, hi, cidrs)
+		return splitRange4(upperHalf, prefix, lo, hi, minPrefix, emit)
 	} else {
-		err := splitRange4(lowerHalf, prefix, lo, broadcast4(lowerHalf, prefix), cidrs)
+		err := splitRange4(lowerHalf, prefix, lo, broadcast4(lowerHalf, prefix), minPrefix, emit)
 		if err != nil {
 			return err
 		}
-		return splitRange4(upperHalf, prefix, upperHalf, hi, cidrs)
+		return splitRange4(upperHalf, prefix, upperHalf, hi, minPrefix, emit)
 	}
 }
 
@@ -148,14 +180,14 @@ func (c cidrBlock4s) Swap(i, j int) {
 	c[i], c[j] = c[j], c[i]
 }
 
-// merge4 accepts a list of IPv4 networks and merges them into the smallest possible list of IPNets.
-// It merges adjacent subnets where possible, those contained within others and removes any duplicates.
-func merge4(blocks cidrBlock4s) ([]*net.IPNet, error) {
+// coalesce4 sorts the IPv4 blocks and coalesces those that overlap or are adjacent.
+// The remaining blocks are returned in ascending order.
+func coalesce4(blocks cidrBlock4s) cidrBlock4s {
 	sort.Sort(blocks)
 
 	// Coalesce overlapping blocks.
 	for i := len(blocks) - 1; i > 0; i-- {
-		if blocks[i].first <= blocks[i-1].last+1 {
+		if blocks[i].first <= blocks[i-1].last || blocks[i].first-1 == blocks[i-1].last {
 			blocks[i-1].last = blocks[i].last
 			if blocks[i].first < blocks[i-1].first {
 				blocks[i-1].first = blocks[i].first
@@ -164,16 +196,151 @@ func merge4(blocks cidrBlock4s) ([]*net.IPNet, error) {
 		}
 	}
 
-	var merged []*net.IPNet
+	var coalesced cidrBlock4s
 	for _, block := range blocks {
-		if block == nil {
-			continue
+		if block != nil {
+			coalesced = append(coalesced, block)
+		}
+	}
+
+	return coalesced
+}
+
+// remove4 removes the exclude blocks from the base blocks, returning the remaining blocks in ascending order.
+func remove4(base, exclude cidrBlock4s) cidrBlock4s {
+	base = coalesce4(base)
+	exclude = coalesce4(exclude)
+
+	var remaining cidrBlock4s
+	j := 0
+	for _, block := range base {
+		// Skip exclusions entirely below this block.
+		for j < len(exclude) && exclude[j].last < block.first {
+			j++
+		}
+
+		first, last := block.first, block.last
+		covered := false
+		for k := j; k < len(exclude) && exclude[k].first <= last; k++ {
+			if exclude[k].first > first {
+				remaining = append(remaining, &cidrBlock4{first: first, last: exclude[k].first - 1})
+			}
+			if exclude[k].last >= last {
+				covered = true
+				break
+			}
+			first = exclude[k].last + 1
+		}
+		if !covered {
+			remaining = append(remaining, &cidrBlock4{first: first, last: last})
+		}
+	}
+
+	return remaining
+}
+
+// intersect4 intersects the two lists of IPv4 blocks, returning the common blocks in ascending order.
+func intersect4(a, b cidrBlock4s) cidrBlock4s {
+	a = coalesce4(a)
+	b = coalesce4(b)
+
+	var common cidrBlock4s
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		first := a[i].first
+		if b[j].first > first {
+			first = b[j].first
+		}
+		last := a[i].last
+		if b[j].last < last {
+			last = b[j].last
+		}
+		if first <= last {
+			common = append(common, &cidrBlock4{first: first, last: last})
 		}
 
-		if err := splitRange4(0, 0, block.first, block.last, &merged); err != nil {
+		if a[i].last < b[j].last {
+			i++
+		} else {
+			j++
+		}
+	}
+
+	return common
+}
+
+// copy returns a deep copy of the IPv4 blocks.
+func (c cidrBlock4s) copy() cidrBlock4s {
+	blocks := make(cidrBlock4s, len(c))
+	for i, block := range c {
+		b := *block
+		blocks[i] = &b
+	}
+
+	return blocks
+}
+
+// equal reports whether the two lists of IPv4 blocks are identical.
+func (c cidrBlock4s) equal(other cidrBlock4s) bool {
+	if len(c) != len(other) {
+		return false
+	}
+	for i := range c {
+		if c[i].first != other[i].first || c[i].last != other[i].last {
+			return false
+		}
+	}
+
+	return true
+}
+
+// toIPNets computes the CIDR blocks covering each of the IPv4 blocks.
+func (c cidrBlock4s) toIPNets() ([]*net.IPNet, error) {
+	return c.toIPNetsMinPrefix(0)
+}
+
+// minPrefixSplits4 returns the number of boundaries between blocks of length minPrefix within the range lo-hi,
+// the number of blocks splitting the range at minPrefix adds.
+func minPrefixSplits4(lo, hi uint32, minPrefix uint) uint64 {
+	shift := widthUInt32 - minPrefix
+	return uint64(hi>>shift) - uint64(lo>>shift)
+}
+
+// toIPNetsMinPrefix computes the CIDR blocks covering each of the IPv4 blocks, none shorter than minPrefix.
+// It returns ErrTooManyBlocks if splitting the blocks at minPrefix would add more than MaxSubnets blocks.
+func (c cidrBlock4s) toIPNetsMinPrefix(minPrefix uint) ([]*net.IPNet, error) {
+	var splits uint64
+	for _, block := range c {
+		splits += minPrefixSplits4(block.first, block.last, minPrefix)
+		if splits > MaxSubnets {
+			return nil, ErrTooManyBlocks
+		}
+	}
+
+	var nets []*net.IPNet
+	for _, block := range c {
+		if err := splitRange4(0, 0, block.first, block.last, minPrefix, appendIPNet4(&nets)); err != nil {
 			return nil, err
 		}
 	}
 
-	return merged, nil
+	return nets, nil
+}
+
+// toPrefixes computes the CIDR blocks covering each of the IPv4 blocks.
+func (c cidrBlock4s) toPrefixes() ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, block := range c {
+		if err := splitRange4(0, 0, block.first, block.last, 0, appendPrefix4(&prefixes)); err != nil {
+			return nil, err
+		}
+	}
+
+	return prefixes, nil
+}
+
+// merge4 accepts a list of IPv4 networks and merges them into the smallest possible list of IPNets.
+// It merges adjacent subnets where possible, those contained within others and removes any duplicates.
+func merge4(blocks cidrBlock4s) ([]*net.IPNet, error) {
+	return coalesce4(blocks).toIPNets()
 }
@@ -2,114 +2,117 @@ package cidrman
 
 import (
 	"fmt"
-	"math/big"
+	"math"
 	"net"
+	"net/netip"
 	"sort"
 )
 
 const widthUInt128 = 128
 
-var maxUInt128 = big.NewInt(0).Sub(big.NewInt(0).Exp(big.NewInt(2), big.NewInt(widthUInt128), nil), big.NewInt(1))
+var maxUInt128 = uint128{hi: math.MaxUint64, lo: math.MaxUint64}
 
 // ipv6ToUInt128 converts an IPv6 address to an unsigned 128-bit integer.
-func ipv6ToUInt128(ip net.IP) *big.Int {
-	return big.NewInt(0).SetBytes(ip)
+func ipv6ToUInt128(ip net.IP) uint128 {
+	return uint128FromBytes(ip)
 }
 
 // uint128ToIPV6 converts an unsigned 128-bit integer to an IPv6 address.
-func uint128ToIPV6(addr *big.Int) net.IP {
+func uint128ToIPV6(addr uint128) net.IP {
 	ip := make([]byte, net.IPv6len)
-	ab := addr.Bytes()
-	copy(ip[len(ip)-len(ab):], ab)
+	addr.putBytes(ip)
 	return ip
 }
 
-// copyUInt128 copies an unsigned 128-bit integer.
-func copyUInt128(x *big.Int) *big.Int {
-	return big.NewInt(0).Set(x)
+// addr6ToUInt128 converts an IPv6 netip address to an unsigned 128-bit integer.
+func addr6ToUInt128(addr netip.Addr) uint128 {
+	b := addr.As16()
+	return uint128FromBytes(b[:])
 }
 
-// hostmask6 returns the hostmask for the specified prefix.
-func hostmask6(prefix uint) *big.Int {
-	z := big.NewInt(0)
-
-	z.Lsh(big.NewInt(1), widthUInt128-prefix)
-	z.Sub(z, big.NewInt(1))
+// uint128ToAddr6 converts an unsigned 128-bit integer to an IPv6 netip address.
+func uint128ToAddr6(addr uint128) netip.Addr {
+	var b [net.IPv6len]byte
+	addr.putBytes(b[:])
+	return netip.AddrFrom16(b)
+}
 
-	return z
+// hostmask6 returns the hostmask for the specified prefix.
+func hostmask6(prefix uint) uint128 {
+	return maxUInt128.rsh(prefix)
 }
 
 // netmask6 returns the netmask for the specified prefix.
-func netmask6(prefix uint) *big.Int {
-	z := big.NewInt(0)
-
-	if prefix == 0 {
-		return z
-	}
-
-	z.Xor(maxUInt128, hostmask6(prefix))
-
-	return z
+func netmask6(prefix uint) uint128 {
+	return hostmask6(prefix).not()
 }
 
 // broadcast6 returns the broadcast address for the given address and prefix.
-func broadcast6(addr *big.Int, prefix uint) *big.Int {
-	z := big.NewInt(0)
-
-	z.Or(addr, hostmask6(prefix))
-
-	return z
+func broadcast6(addr uint128, prefix uint) uint128 {
+	return addr.or(hostmask6(prefix))
 }
 
 // network6 returns the network address for the given address and prefix.
-func network6(addr *big.Int, prefix uint) *big.Int {
-	z := big.NewInt(0)
+func network6(addr uint128, prefix uint) uint128 {
+	return addr.and(netmask6(prefix))
+}
+
+// emit6 receives each of the IPv6 CIDR blocks computed by splitRange6.
+type emit6 func(addr uint128, prefix uint)
 
-	z.And(addr, netmask6(prefix))
+// appendIPNet6 returns an emit6 that appends the CIDR blocks to a list of IPNets.
+func appendIPNet6(cidrs *[]*net.IPNet) emit6 {
+	return func(addr uint128, prefix uint) {
+		cidr := net.IPNet{IP: uint128ToIPV6(addr), Mask: net.CIDRMask(int(prefix), 8*net.IPv6len)}
+		*cidrs = append(*cidrs, &cidr)
+	}
+}
 
-	return z
+// appendPrefix6 returns an emit6 that appends the CIDR blocks to a list of prefixes.
+func appendPrefix6(prefixes *[]netip.Prefix) emit6 {
+	return func(addr uint128, prefix uint) {
+		*prefixes = append(*prefixes, netip.PrefixFrom(uint128ToAddr6(addr), int(prefix)))
+	}
 }
 
 // splitRange6 recursively computes the CIDR blocks to cover the range lo to hi.
-func splitRange6(addr *big.Int, prefix uint, lo, hi *big.Int, cidrs *[]*net.IPNet) error {
+// Blocks shorter than minPrefix are split further instead of being aggregated.
+func splitRange6(addr uint128, prefix uint, lo, hi uint128, minPrefix uint, emit emit6) error {
 	if prefix > widthUInt128 {
-		return fmt.Errorf("Invalid mask size: %d", prefix)
+		return &PrefixLengthError{Prefix: int(prefix), Min: 0, Max: widthUInt128}
 	}
 
 	bc := broadcast6(addr, prefix)
-	//	fmt.Printf("%v/%v, %v-%v, %v\n", uint128ToIPV6(addr), prefix, uint128ToIPV6(lo), uint128ToIPV6(hi), uint128ToIPV6(bc))
-	if (lo.Cmp(addr) < 0) || (hi.Cmp(bc) > 0) {
+	if (lo.cmp(addr) < 0) || (hi.cmp(bc) > 0) {
 		return fmt.Errorf("%v, %v out of range for network %v/%d, broadcast %v", uint128ToIPV6(lo), uint128ToIPV6(hi), uint128ToIPV6(addr), prefix, uint128ToIPV6(bc))
 	}
 
-	if (lo.Cmp(addr) == 0) && (hi.Cmp(bc) == 0) {
-		cidr := net.IPNet{IP: uint128ToIPV6(addr), Mask: net.CIDRMask(int(prefix), 8*net.IPv6len)}
-		*cidrs = append(*cidrs, &cidr)
+	if (lo.cmp(addr) == 0) && (hi.cmp(bc) == 0) && (prefix >= minPrefix) {
+		emit(addr, prefix)
 		return nil
 	}
 
 	prefix++
-	lowerHalf := copyUInt128(addr)
-	upperHalf := copyUInt128(addr)
-	upperHalf.SetBit(upperHalf, int(widthUInt128-prefix), 1)
-	if hi.Cmp(upperHalf) < 0 {
-		return splitRange6(lowerHalf, prefix, lo, hi, cidrs)
-	} else if lo.Cmp(upperHalf) >= 0 {
-		return splitRange6(upperHalf, prefix, lo, hi, cidrs)
+	lowerHalf := addr
+	upperHalf := addr.setBit(widthUInt128 - prefix)
+	if hi.cmp(upperHalf) < 0 {
+		return splitRange6(lowerHalf, prefix, lo, hi, minPrefix, emit)
+	} else if lo.cmp(upperHalf) >= 0 {
+		return splitRange6(upperHalf, prefix, lo, hi, minPrefix, emit)
 	} else {
-		err := splitRange6(lowerHalf, prefix, lo, broadcast6(lowerHalf, prefix), cidrs)
+		err := splitRange6(lowerHalf, prefix, lo, broadcast6(lowerHalf, prefix), minPrefix, emit)
 		if err != nil {
 			return err
 		}
-		return splitRange6(upperHalf, prefix, upperHalf, hi, cidrs)
+		return splitRange6(upperHalf, prefix, upperHalf, hi, minPrefix, emit)
 	}
 }
 
 // IPv6 CIDR block.
 
 type cidrBlock6 struct {
-	first *big.Int
-	last  *big.Int
+	first uint128
+	last  uint128
 }
 
 type cidrBlock6s []*cidrBlock6
@@ -136,53 +139,184 @@ func (c cidrBlock6s) Less(i, j int) bool {
 	rhs := c[j]
 
 	// By last IP in the range.
-	if lhs.last.Cmp(rhs.last) < 0 {
-		return true
-	} else if lhs.last.Cmp(rhs.last) > 0 {
-		return false
+	if cmp := lhs.last.cmp(rhs.last); cmp != 0 {
+		return cmp < 0
 	}
 
 	// Then by first IP in the range.
-	if lhs.first.Cmp(rhs.first) < 0 {
-		return true
-	} else if lhs.first.Cmp(rhs.first) > 0 {
-		return false
-	}
-
-	return false
+	return lhs.first.cmp(rhs.first) < 0
 }
 
 func (c cidrBlock6s) Swap(i, j int) {
 	c[i], c[j] = c[j], c[i]
 }
 
-// merge6 accepts a list of IPv6 networks and merges them into the smallest possible list of IPNets.
-// It merges adjacent subnets where possible, those contained within others and removes any duplicates.
-func merge6(blocks cidrBlock6s) ([]*net.IPNet, error) {
+// coalesce6 sorts the IPv6 blocks and coalesces those that overlap or are adjacent.
+// The remaining blocks are returned in ascending order.
+func coalesce6(blocks cidrBlock6s) cidrBlock6s {
 	sort.Sort(blocks)
 
 	// Coalesce overlapping blocks.
 	for i := len(blocks) - 1; i > 0; i-- {
-		cmp := big.NewInt(1)
-		cmp.Add(cmp, blocks[i-1].last)
-		if blocks[i].first.Cmp(cmp) <= 0 {
+		if blocks[i].first.cmp(blocks[i-1].last) <= 0 || blocks[i].first.subOne() == blocks[i-1].last {
 			blocks[i-1].last = blocks[i].last
-			if blocks[i].first.Cmp(blocks[i-1].first) < 0 {
+			if blocks[i].first.cmp(blocks[i-1].first) < 0 {
 				blocks[i-1].first = blocks[i].first
 			}
 			blocks[i] = nil
 		}
 	}
 
-	var merged []*net.IPNet
+	var coalesced cidrBlock6s
 	for _, block := range blocks {
-		if block == nil {
-			continue
+		if block != nil {
+			coalesced = append(coalesced, block)
+		}
+	}
+
+	return coalesced
+}
+
+// remove6 removes the exclude blocks from the base blocks, returning the remaining blocks in ascending order.
+func remove6(base, exclude cidrBlock6s) cidrBlock6s {
+	base = coalesce6(base)
+	exclude = coalesce6(exclude)
+
+	var remaining cidrBlock6s
+	j := 0
+	for _, block := range base {
+		// Skip exclusions entirely below this block.
+		for j < len(exclude) && exclude[j].last.cmp(block.first) < 0 {
+			j++
+		}
+
+		first, last := block.first, block.last
+		covered := false
+		for k := j; k < len(exclude) && exclude[k].first.cmp(last) <= 0; k++ {
+			if exclude[k].first.cmp(first) > 0 {
+				remaining = append(remaining, &cidrBlock6{first: first, last: exclude[k].first.subOne()})
+			}
+			if exclude[k].last.cmp(last) >= 0 {
+				covered = true
+				break
+			}
+			first = exclude[k].last.addOne()
 		}
+		if !covered {
+			remaining = append(remaining, &cidrBlock6{first: first, last: last})
+		}
+	}
 
-		if err := splitRange6(big.NewInt(0), 0, block.first, block.last, &merged); err != nil {
+	return remaining
+}
+
+// intersect6 intersects the two lists of IPv6 blocks, returning the common blocks in ascending order.
+func intersect6(a, b cidrBlock6s) cidrBlock6s {
+	a = coalesce6(a)
+	b = coalesce6(b)
+
+	var common cidrBlock6s
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		first := a[i].first
+		if b[j].first.cmp(first) > 0 {
+			first = b[j].first
+		}
+		last := a[i].last
+		if b[j].last.cmp(last) < 0 {
+			last = b[j].last
+		}
+		if first.cmp(last) <= 0 {
+			common = append(common, &cidrBlock6{first: first, last: last})
+		}
+
+		if a[i].last.cmp(b[j].last) < 0 {
+			i++
+		} else {
+			j++
+		}
+	}
+
+	return common
+}
+
+// copy returns a deep copy of the IPv6 blocks.
+func (c cidrBlock6s) copy() cidrBlock6s {
+	blocks := make(cidrBlock6s, len(c))
+	for i, block := range c {
+		b := *block
+		blocks[i] = &b
+	}
+
+	return blocks
+}
+
+// equal reports whether the two lists of IPv6 blocks are identical.
+func (c cidrBlock6s) equal(other cidrBlock6s) bool {
+	if len(c) != len(other) {
+		return false
+	}
+	for i := range c {
+		if c[i].first != other[i].first || c[i].last != other[i].last {
+			return false
+		}
+	}
+
+	return true
+}
+
+// toIPNets computes the CIDR blocks covering each of the IPv6 blocks.
+func (c cidrBlock6s) toIPNets() ([]*net.IPNet, error) {
+	return c.toIPNetsMinPrefix(0)
+}
+
+// minPrefixSplits6 returns the number of boundaries between blocks of length minPrefix within the range lo-hi,
+// the number of blocks splitting the range at minPrefix adds, saturated at the maximum uint64.
+func minPrefixSplits6(lo, hi uint128, minPrefix uint) uint64 {
+	shift := widthUInt128 - minPrefix
+	splits := hi.rsh(shift).sub(lo.rsh(shift))
+	if splits.hi != 0 {
+		return math.MaxUint64
+	}
+	return splits.lo
+}
+
+// toIPNetsMinPrefix computes the CIDR blocks covering each of the IPv6 blocks, none shorter than minPrefix.
+// It returns ErrTooManyBlocks if splitting the blocks at minPrefix would add more than MaxSubnets blocks.
+func (c cidrBlock6s) toIPNetsMinPrefix(minPrefix uint) ([]*net.IPNet, error) {
+	var splits uint64
+	for _, block := range c {
+		n := minPrefixSplits6(block.first, block.last, minPrefix)
+		if n > MaxSubnets-splits {
+			return nil, ErrTooManyBlocks
+		}
+		splits += n
+	}
+
+	var nets []*net.IPNet
+	for _, block := range c {
+		if err := splitRange6(uint128{}, 0, block.first, block.last, minPrefix, appendIPNet6(&nets)); err != nil {
 			return nil, err
 		}
 	}
-	return merged, nil
+
+	return nets, nil
+}
+
+// toPrefixes computes the CIDR blocks covering each of the IPv6 blocks.
+func (c cidrBlock6s) toPrefixes() ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, block := range c {
+		if err := splitRange6(uint128{}, 0, block.first, block.last, 0, appendPrefix6(&prefixes)); err != nil {
+			return nil, err
+		}
+	}
+
+	return prefixes, nil
+}
+
+// merge6 accepts a list of IPv6 networks and merges them into the smallest possible list of IPNets.
+// It merges adjacent subnets where possible, those contained within others and removes any duplicates.
+func merge6(blocks cidrBlock6s) ([]*net.IPNet, error) {
+	return coalesce6(blocks).toIPNets()
 }
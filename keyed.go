@@ -0,0 +1,127 @@
+package cidrman
+
+import (
+	"net"
+)
+
+// Keyed is a CIDR block with a key, such as its next hop or community, that blocks must share to be merged.
+type Keyed[K comparable] struct {
+	CIDR string
+	Key  K
+}
+
+// keyedLevel4 holds the IPv4 blocks of one prefix length, grouped by key.
+type keyedLevel4[K comparable] struct {
+	keys   []K
+	groups map[K]cidrBlock4s
+	seen   map[cidrBlock4]bool
+}
+
+// keyedLevel6 holds the IPv6 blocks of one prefix length, grouped by key.
+type keyedLevel6[K comparable] struct {
+	keys   []K
+	groups map[K]cidrBlock6s
+	seen   map[cidrBlock6]bool
+}
+
+// MergeKeyed accepts a list of keyed CIDR blocks and merges the blocks sharing a key into the smallest
+// possible list of CIDRs per key. Where blocks with different keys overlap, the more specific block wins,
+// so the lists of different keys never overlap. Of identical blocks with different keys the first one wins.
+// Keys left without any address space are omitted.
+func MergeKeyed[K comparable](inputs []Keyed[K]) (map[K][]string, error) {
+	if inputs == nil {
+		return nil, nil
+	}
+
+	// Bucket the blocks by prefix length, keeping the first key of identical blocks.
+	var levels4 [widthUInt32 + 1]keyedLevel4[K]
+	var levels6 [widthUInt128 + 1]keyedLevel6[K]
+	for i, input := range inputs {
+		_, network, err := net.ParseCIDR(input.CIDR)
+		if err != nil {
+			return nil, &ParseError{Kind: KindCIDR, Input: input.CIDR, Index: i}
+		}
+		prefix, _ := network.Mask.Size()
+
+		if ip4 := network.IP.To4(); ip4 != nil {
+			level := &levels4[prefix]
+			block := newBlock4(ip4, network.Mask)
+			if level.seen == nil {
+				level.groups = make(map[K]cidrBlock4s)
+				level.seen = make(map[cidrBlock4]bool)
+			}
+			if level.seen[*block] {
+				continue
+			}
+			level.seen[*block] = true
+			if _, ok := level.groups[input.Key]; !ok {
+				level.keys = append(level.keys, input.Key)
+			}
+			level.groups[input.Key] = append(level.groups[input.Key], block)
+		} else {
+			level := &levels6[prefix]
+			block := newBlock6(network.IP.To16(), network.Mask)
+			if level.seen == nil {
+				level.groups = make(map[K]cidrBlock6s)
+				level.seen = make(map[cidrBlock6]bool)
+			}
+			if level.seen[*block] {
+				continue
+			}
+			level.seen[*block] = true
+			if _, ok := level.groups[input.Key]; !ok {
+				level.keys = append(level.keys, input.Key)
+			}
+			level.groups[input.Key] = append(level.groups[input.Key], block)
+		}
+	}
+
+	// From the most specific prefix length to the least, each key gets the space not already
+	// claimed by a more specific block.
+	owned4 := make(map[K]cidrBlock4s)
+	var claimed4 cidrBlock4s
+	for prefix := widthUInt32; prefix >= 0; prefix-- {
+		level := &levels4[prefix]
+		for _, key := range level.keys {
+			group := level.groups[key]
+			owned4[key] = append(owned4[key], remove4(group.copy(), claimed4.copy())...)
+			claimed4 = append(claimed4, group.copy()...)
+		}
+		claimed4 = coalesce4(claimed4)
+	}
+
+	owned6 := make(map[K]cidrBlock6s)
+	var claimed6 cidrBlock6s
+	for prefix := widthUInt128; prefix >= 0; prefix-- {
+		level := &levels6[prefix]
+		for _, key := range level.keys {
+			group := level.groups[key]
+			owned6[key] = append(owned6[key], remove6(group.copy(), claimed6.copy())...)
+			claimed6 = append(claimed6, group.copy()...)
+		}
+		claimed6 = coalesce6(claimed6)
+	}
+
+	merged := make(map[K][]string)
+	for key, blocks := range owned4 {
+		nets, err := coalesce4(blocks).toIPNets()
+		if err != nil {
+			return nil, err
+		}
+		merged[key] = append(merged[key], ipNets(nets).toCIDRs()...)
+	}
+	for key, blocks := range owned6 {
+		nets, err := coalesce6(blocks).toIPNets()
+		if err != nil {
+			return nil, err
+		}
+		merged[key] = append(merged[key], ipNets(nets).toCIDRs()...)
+	}
+	for key, cidrs := range merged {
+		if len(cidrs) == 0 {
+			delete(merged, key)
+		}
+	}
+
+	return merged, nil
+}
@@ -0,0 +1,101 @@
+// go test -v -run="TestMergeKeyed"
+
+package cidrman
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeKeyed(t *testing.T) {
+	type TestCase struct {
+		Input  []Keyed[string]
+		Output map[string][]string
+		Error  bool
+	}
+
+	testCases := []TestCase{
+		{
+			Input:  nil,
+			Output: nil,
+			Error:  false,
+		},
+		{
+			Input:  []Keyed[string]{},
+			Output: map[string][]string{},
+			Error:  false,
+		},
+		{
+			Input: []Keyed[string]{
+				{CIDR: "abcdefgh", Key: "a"},
+			},
+			Output: nil,
+			Error:  true,
+		},
+		{
+			// Adjacent blocks only merge when they share a key.
+			Input: []Keyed[string]{
+				{CIDR: "192.0.2.0/25", Key: "hop1"},
+				{CIDR: "192.0.2.128/25", Key: "hop1"},
+				{CIDR: "198.51.100.0/25", Key: "hop1"},
+				{CIDR: "198.51.100.128/25", Key: "hop2"},
+			},
+			Output: map[string][]string{
+				"hop1": {"192.0.2.0/24", "198.51.100.0/25"},
+				"hop2": {"198.51.100.128/25"},
+			},
+			Error: false,
+		},
+		{
+			// The more specific block wins.
+			Input: []Keyed[string]{
+				{CIDR: "10.0.0.0/8", Key: "hop1"},
+				{CIDR: "10.128.0.0/9", Key: "hop2"},
+				{CIDR: "10.128.0.0/10", Key: "hop1"},
+			},
+			Output: map[string][]string{
+				"hop1": {"10.0.0.0/9", "10.128.0.0/10"},
+				"hop2": {"10.192.0.0/10"},
+			},
+			Error: false,
+		},
+		{
+			// The first of identical blocks wins, and keys left without space are omitted.
+			Input: []Keyed[string]{
+				{CIDR: "192.0.2.0/24", Key: "hop1"},
+				{CIDR: "192.0.2.0/24", Key: "hop2"},
+				{CIDR: "2001:db8::/32", Key: "hop2"},
+				{CIDR: "2001:db8::/33", Key: "hop3"},
+				{CIDR: "2001:db8:8000::/33", Key: "hop3"},
+			},
+			Output: map[string][]string{
+				"hop1": {"192.0.2.0/24"},
+				"hop3": {"2001:db8::/32"},
+			},
+			Error: false,
+		},
+		{
+			Input: []Keyed[string]{
+				{CIDR: "192.0.2.0/24", Key: "hop1"},
+				{CIDR: "2001:db8::/32", Key: "hop1"},
+			},
+			Output: map[string][]string{
+				"hop1": {"192.0.2.0/24", "2001:db8::/32"},
+			},
+			Error: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		output, err := MergeKeyed(testCase.Input)
+		if err != nil {
+			if !testCase.Error {
+				t.Errorf("MergeKeyed(%#v) failed: %s", testCase.Input, err.Error())
+			}
+			continue
+		}
+		if !reflect.DeepEqual(testCase.Output, output) {
+			t.Errorf("MergeKeyed(%#v) expected: %#v, got: %#v", testCase.Input, testCase.Output, output)
+		}
+	}
+}
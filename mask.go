@@ -0,0 +1,276 @@
+package cidrman
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+	"net"
+	"strings"
+)
+
+// Notation is a way of writing an IPv4 CIDR block.
+type Notation int
+
+const (
+	// NotationCIDR writes the prefix length after a slash, as in 10.0.0.0/16.
+	NotationCIDR Notation = iota
+	// NotationNetmask writes a dotted netmask after the address, as in 10.0.0.0 255.255.0.0.
+	NotationNetmask
+	// NotationWildcard writes a Cisco-style wildcard (inverse) mask after the address, as in 10.0.0.0 0.0.255.255.
+	NotationWildcard
+)
+
+// maxWildcardBits is the most non-contiguous ones of a wildcard mask that are expanded,
+// giving at most 65536 CIDR blocks.
+const maxWildcardBits = 16
+
+// parseMask4 parses an IPv4 address and dotted mask, returning them as unsigned integers.
+func parseMask4(address, mask string) (uint32, uint32, bool) {
+	ip := net.ParseIP(address).To4()
+	m := net.ParseIP(mask).To4()
+	if ip == nil || m == nil || strings.Contains(address, ":") || strings.Contains(mask, ":") {
+		return 0, 0, false
+	}
+
+	return ipv4ToUInt32(ip), ipv4ToUInt32(m), true
+}
+
+// wildcard4 converts a dotted mask to a wildcard mask and reports whether it is contiguous.
+// A mask of leading ones is a netmask and a mask of trailing ones a wildcard mask. The masks
+// 0.0.0.0 and 255.255.255.255 are both, and match a /0 for the address 0.0.0.0 and a /32 otherwise,
+// as in the Cisco "0.0.0.0 0.0.0.0" default route, "any" and host entries. Other non-contiguous masks
+// are taken to be netmasks if the first bit is set and wildcard masks otherwise.
+func wildcard4(addr, mask uint32) (uint32, bool) {
+	if mask == 0 || mask == maxUInt32 {
+		if addr == 0 {
+			return maxUInt32, true
+		}
+		return 0, true
+	}
+
+	if mask&(1<<(widthUInt32-1)) != 0 {
+		mask = ^mask
+	}
+	prefix := uint(bits.LeadingZeros32(mask))
+
+	return mask, mask == hostmask4(prefix)
+}
+
+// wildcardBits4 splits an IPv4 wildcard mask into the prefix length of the blocks it matches,
+// given by its trailing ones, and the remaining scattered ones.
+func wildcardBits4(wildcard uint32) (uint, uint32) {
+	prefix := widthUInt32 - uint(bits.TrailingZeros32(^wildcard))
+	return prefix, wildcard &^ hostmask4(prefix)
+}
+
+// expandWildcard4 emits the CIDR blocks matching the address with the wildcard mask, in ascending order,
+// one for each combination of the scattered ones of the mask.
+func expandWildcard4(addr, wildcard uint32, emit emit4) error {
+	prefix, scattered := wildcardBits4(wildcard)
+	if bits.OnesCount32(scattered) > maxWildcardBits {
+		return ErrTooManyBlocks
+	}
+
+	// Step through the subsets of the scattered bits in ascending order.
+	base := addr &^ wildcard
+	for sub := uint32(0); ; sub = (sub - scattered) & scattered {
+		emit(base|sub, prefix)
+		if sub == scattered {
+			return nil
+		}
+	}
+}
+
+// wildcardBits6 splits an IPv6 wildcard mask into the prefix length of the blocks it matches,
+// given by its trailing ones, and the remaining scattered ones.
+func wildcardBits6(wildcard uint128) (uint, uint128) {
+	prefix := widthUInt128 - uint(wildcard.not().trailingZeros())
+	return prefix, wildcard.and(netmask6(prefix))
+}
+
+// expandWildcard6 emits the CIDR blocks matching the address with the wildcard mask, in ascending order,
+// one for each combination of the scattered ones of the mask.
+func expandWildcard6(addr, wildcard uint128, emit emit6) error {
+	prefix, scattered := wildcardBits6(wildcard)
+	if scattered.onesCount() > maxWildcardBits {
+		return ErrTooManyBlocks
+	}
+
+	// Step through the subsets of the scattered bits in ascending order.
+	base := addr.and(wildcard.not())
+	for sub := (uint128{}); ; sub = sub.sub(scattered).and(scattered) {
+		emit(base.or(sub), prefix)
+		if sub == scattered {
+			return nil
+		}
+	}
+}
+
+// ExpandWildcard accepts an address and a wildcard mask of the same family, such as 10.0.0.0 and 0.255.0.255,
+// where each one bit of the mask matches either value of the bit in the address. It returns the CIDR blocks
+// matching the pattern in ascending order, the smallest possible list as blocks differing in the scattered
+// ones of the mask are never adjacent, and the number of blocks. If the pattern matches more than 65536 blocks,
+// only the number is returned, with ErrTooManyBlocks.
+func ExpandWildcard(addr, wildcard string) ([]string, *big.Int, error) {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return nil, nil, &ParseError{Kind: KindIP, Input: addr, Index: -1}
+	}
+	mask := net.ParseIP(wildcard)
+	if mask == nil {
+		return nil, nil, &ParseError{Kind: KindIP, Input: wildcard, Index: -1}
+	}
+
+	var nets []*net.IPNet
+	var n int
+	var err error
+	is6 := strings.Contains(addr, ":")
+	switch {
+	case is6 != strings.Contains(wildcard, ":"):
+		return nil, nil, ErrMismatchedFamily
+	case !is6:
+		_, scattered := wildcardBits4(ipv4ToUInt32(mask.To4()))
+		n = bits.OnesCount32(scattered)
+		err = expandWildcard4(ipv4ToUInt32(ip.To4()), ipv4ToUInt32(mask.To4()), appendIPNet4(&nets))
+	default:
+		_, scattered := wildcardBits6(ipv6ToUInt128(mask.To16()))
+		n = scattered.onesCount()
+		err = expandWildcard6(ipv6ToUInt128(ip.To16()), ipv6ToUInt128(mask.To16()), appendIPNet6(&nets))
+	}
+
+	count := new(big.Int).Lsh(big.NewInt(1), uint(n))
+	if err != nil {
+		return nil, count, err
+	}
+
+	return ipNets(nets).toCIDRs(), count, nil
+}
+
+// parseMaskedAddr parses an IPv4 address followed by a netmask or wildcard mask, returning the address
+// as given, with any host bits, and the mask as a wildcard mask. A non-contiguous mask is rejected unless
+// expand is set. Errors report the index of the CIDR block in a list, or -1.
+func parseMaskedAddr(s string, index int, expand bool) (uint32, uint32, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return 0, 0, &ParseError{Kind: KindCIDR, Input: s, Index: index}
+	}
+	addr, mask, ok := parseMask4(fields[0], fields[1])
+	if !ok {
+		return 0, 0, &ParseError{Kind: KindCIDR, Input: s, Index: index}
+	}
+
+	wildcard, contiguous := wildcard4(addr, mask)
+	if !contiguous && !expand {
+		return 0, 0, &ParseError{Kind: KindCIDR, Input: s, Index: index, Err: ErrNonContiguousMask}
+	}
+
+	return addr, wildcard, nil
+}
+
+// parseMasked parses a CIDR block in any notation, emitting the blocks it matches.
+// A non-contiguous mask is expanded if expand is set and rejected otherwise.
+// Errors report the index of the CIDR block in a list, or -1.
+func parseMasked(s string, index int, expand bool, emit func(*net.IPNet)) error {
+	if strings.Contains(s, "/") {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(s))
+		if err != nil {
+			return &ParseError{Kind: KindCIDR, Input: s, Index: index}
+		}
+		emit(network)
+		return nil
+	}
+
+	addr, wildcard, err := parseMaskedAddr(s, index, expand)
+	if err != nil {
+		return err
+	}
+
+	var nets []*net.IPNet
+	if err := expandWildcard4(addr, wildcard, appendIPNet4(&nets)); err != nil {
+		return &ParseError{Kind: KindCIDR, Input: s, Index: index, Err: err}
+	}
+	for _, network := range nets {
+		emit(network)
+	}
+
+	return nil
+}
+
+// MaskedToCIDR rewrites a CIDR block in any of the notations accepted by ParseMaskedCIDR in slash notation,
+// keeping the address as given, so 10.1.2.3 255.0.0.0 becomes 10.1.2.3/8. Unlike ParseMaskedCIDR the host
+// bits are not masked, so the result can be passed on to MergeCIDRsWithOptions in strict mode.
+func MaskedToCIDR(s string) (string, error) {
+	if strings.Contains(s, "/") {
+		cidr := strings.TrimSpace(s)
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return "", &ParseError{Kind: KindCIDR, Input: s, Index: -1}
+		}
+		return cidr, nil
+	}
+
+	addr, wildcard, err := parseMaskedAddr(s, -1, false)
+	if err != nil {
+		return "", err
+	}
+	prefix, _ := wildcardBits4(wildcard)
+
+	return fmt.Sprintf("%s/%d", uint32ToIPV4(addr), prefix), nil
+}
+
+// ParseMaskedCIDR parses a CIDR block in slash notation, such as 10.0.0.0/16, or an IPv4 address followed
+// by a netmask, such as 10.0.0.0 255.255.0.0, or a wildcard mask, such as 10.0.0.0 0.0.255.255.
+// Host bits set in the address are masked. A non-contiguous mask, such as 0.255.0.255, is rejected
+// with a *ParseError wrapping ErrNonContiguousMask.
+func ParseMaskedCIDR(s string) (*net.IPNet, error) {
+	var network *net.IPNet
+	if err := parseMasked(s, -1, false, func(n *net.IPNet) { network = n }); err != nil {
+		return nil, err
+	}
+
+	return network, nil
+}
+
+// ParseMaskedCIDRs parses a list of CIDR blocks in any of the notations accepted by ParseMaskedCIDR.
+// If expand is set, an entry with a non-contiguous wildcard mask is expanded into the CIDR blocks it matches,
+// as long as they are no more than 65536, instead of being rejected.
+func ParseMaskedCIDRs(entries []string, expand bool) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for i, entry := range entries {
+		if err := parseMasked(entry, i, expand, func(n *net.IPNet) { nets = append(nets, n) }); err != nil {
+			return nil, err
+		}
+	}
+
+	return nets, nil
+}
+
+// FormatIPNet writes the IP network in the notation. IPv6 networks are always written in CIDR notation.
+func FormatIPNet(network *net.IPNet, notation Notation) string {
+	ip4 := network.IP.To4()
+	ones, width := network.Mask.Size()
+	if ip4 == nil || width != widthUInt32 || notation == NotationCIDR {
+		return network.String()
+	}
+
+	mask := netmask4(uint(ones))
+	if notation == NotationWildcard {
+		mask = hostmask4(uint(ones))
+	}
+
+	return uint32ToIPV4(network4(ipv4ToUInt32(ip4), uint(ones))).String() + " " + uint32ToIPV4(mask).String()
+}
+
+// FormatCIDRs rewrites a list of CIDR blocks, such as the result of MergeCIDRs, in the notation.
+func FormatCIDRs(cidrs []string, notation Notation) ([]string, error) {
+	nets, err := parseCIDRs(cidrs)
+	if err != nil {
+		return nil, err
+	}
+
+	formatted := make([]string, len(nets))
+	for i, network := range nets {
+		formatted[i] = FormatIPNet(network, notation)
+	}
+
+	return formatted, nil
+}
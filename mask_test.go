@@ -0,0 +1,308 @@
+// go test -v -run="TestParseMaskedCIDR"
+
+package cidrman
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseMaskedCIDR(t *testing.T) {
+	type TestCase struct {
+		Input  string
+		Output string
+		Error  error
+	}
+
+	testCases := []TestCase{
+		{Input: "", Error: &ParseError{Kind: KindCIDR, Input: "", Index: -1}},
+		{Input: "10.0.0.0", Error: &ParseError{Kind: KindCIDR, Input: "10.0.0.0", Index: -1}},
+		{Input: "10.0.0.0 255.255.0.0 extra", Error: &ParseError{Kind: KindCIDR, Input: "10.0.0.0 255.255.0.0 extra", Index: -1}},
+		{Input: "10.0.0.0 255.255.0.256", Error: &ParseError{Kind: KindCIDR, Input: "10.0.0.0 255.255.0.256", Index: -1}},
+		{Input: "2001:db8:: ffff:ffff::", Error: &ParseError{Kind: KindCIDR, Input: "2001:db8:: ffff:ffff::", Index: -1}},
+		{Input: "10.0.0.0 0.255.0.255", Error: &ParseError{Kind: KindCIDR, Input: "10.0.0.0 0.255.0.255", Index: -1, Err: ErrNonContiguousMask}},
+		{Input: "10.0.0.0 255.0.255.0", Error: &ParseError{Kind: KindCIDR, Input: "10.0.0.0 255.0.255.0", Index: -1, Err: ErrNonContiguousMask}},
+		{Input: "10.0.0.0/16", Output: "10.0.0.0/16"},
+		{Input: "2001:db8::/32", Output: "2001:db8::/32"},
+		{Input: "10.0.0.0 255.255.0.0", Output: "10.0.0.0/16"},
+		{Input: "10.0.0.0 0.0.255.255", Output: "10.0.0.0/16"},
+		{Input: "  10.1.2.3   255.0.0.0 ", Output: "10.0.0.0/8"},
+		{Input: "192.0.2.1 0.0.0.0", Output: "192.0.2.1/32"},
+		{Input: "192.0.2.1 255.255.255.255", Output: "192.0.2.1/32"},
+		{Input: "0.0.0.0 0.0.0.0", Output: "0.0.0.0/0"},
+		{Input: "0.0.0.0 255.255.255.255", Output: "0.0.0.0/0"},
+		{Input: "192.0.2.0 0.0.0.1", Output: "192.0.2.0/31"},
+		{Input: "192.0.2.0 255.255.255.254", Output: "192.0.2.0/31"},
+	}
+
+	for _, testCase := range testCases {
+		output, err := ParseMaskedCIDR(testCase.Input)
+		if err != nil {
+			if !reflect.DeepEqual(testCase.Error, err) {
+				t.Errorf("ParseMaskedCIDR(%#v) expected error: %#v, got: %#v", testCase.Input, testCase.Error, err)
+			}
+			continue
+		}
+		if testCase.Error != nil {
+			t.Errorf("ParseMaskedCIDR(%#v) expected error: %#v, got: %s", testCase.Input, testCase.Error, output)
+			continue
+		}
+		if output.String() != testCase.Output {
+			t.Errorf("ParseMaskedCIDR(%#v) expected: %s, got: %s", testCase.Input, testCase.Output, output)
+		}
+	}
+}
+
+// go test -v -run="TestParseMaskedCIDRs"
+
+func TestParseMaskedCIDRs(t *testing.T) {
+	type TestCase struct {
+		Input  []string
+		Expand bool
+		Output []string
+		Error  error
+	}
+
+	testCases := []TestCase{
+		{
+			Input:  nil,
+			Output: nil,
+		},
+		{
+			Input:  []string{"10.0.0.0/8", "192.0.2.0 0.0.0.255"},
+			Output: []string{"10.0.0.0/8", "192.0.2.0/24"},
+		},
+		{
+			Input: []string{"10.0.0.0/8", "10.0.0.0 0.0.1.3"},
+			Error: ErrNonContiguousMask,
+		},
+		{
+			Input:  []string{"10.0.0.0/8", "10.0.0.0 0.0.1.3"},
+			Expand: true,
+			Output: []string{"10.0.0.0/8", "10.0.0.0/30", "10.0.1.0/30"},
+		},
+		{
+			Input:  []string{"10.0.0.0 255.255.254.252"},
+			Expand: true,
+			Output: []string{"10.0.0.0/30", "10.0.1.0/30"},
+		},
+		{
+			Input:  []string{"10.0.0.0 0.255.255.254"},
+			Expand: true,
+			Error:  ErrTooManyBlocks,
+		},
+	}
+
+	for _, testCase := range testCases {
+		output, err := ParseMaskedCIDRs(testCase.Input, testCase.Expand)
+		if err != nil {
+			if testCase.Error == nil || !errors.Is(err, testCase.Error) {
+				t.Errorf("ParseMaskedCIDRs(%#v, %t) expected error: %v, got: %v", testCase.Input, testCase.Expand, testCase.Error, err)
+			}
+			continue
+		}
+		if testCase.Error != nil {
+			t.Errorf("ParseMaskedCIDRs(%#v, %t) expected error: %v", testCase.Input, testCase.Expand, testCase.Error)
+			continue
+		}
+		if !reflect.DeepEqual(testCase.Output, ipNets(output).toCIDRs()) {
+			t.Errorf("ParseMaskedCIDRs(%#v, %t) expected: %#v, got: %#v", testCase.Input, testCase.Expand, testCase.Output, ipNets(output).toCIDRs())
+		}
+	}
+}
+
+// go test -v -run="TestFormatCIDRs"
+
+func TestFormatCIDRs(t *testing.T) {
+	type TestCase struct {
+		Input    []string
+		Notation Notation
+		Output   []string
+		Error    bool
+	}
+
+	input := []string{"0.0.0.0/0", "10.0.0.0/15", "192.0.2.1/32", "2001:db8::/32"}
+
+	testCases := []TestCase{
+		{
+			Input:    []string{"10.0.0.0/33"},
+			Notation: NotationNetmask,
+			Error:    true,
+		},
+		{
+			Input:    input,
+			Notation: NotationCIDR,
+			Output:   input,
+		},
+		{
+			Input:    input,
+			Notation: NotationNetmask,
+			Output:   []string{"0.0.0.0 0.0.0.0", "10.0.0.0 255.254.0.0", "192.0.2.1 255.255.255.255", "2001:db8::/32"},
+		},
+		{
+			Input:    input,
+			Notation: NotationWildcard,
+			Output:   []string{"0.0.0.0 255.255.255.255", "10.0.0.0 0.1.255.255", "192.0.2.1 0.0.0.0", "2001:db8::/32"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		output, err := FormatCIDRs(testCase.Input, testCase.Notation)
+		if err != nil {
+			if !testCase.Error {
+				t.Errorf("FormatCIDRs(%#v, %d) failed: %s", testCase.Input, testCase.Notation, err.Error())
+			}
+			continue
+		}
+		if testCase.Error {
+			t.Errorf("FormatCIDRs(%#v, %d) expected error, got: %#v", testCase.Input, testCase.Notation, output)
+			continue
+		}
+		if !reflect.DeepEqual(testCase.Output, output) {
+			t.Errorf("FormatCIDRs(%#v, %d) expected: %#v, got: %#v", testCase.Input, testCase.Notation, testCase.Output, output)
+		}
+
+		// The formatted CIDR blocks parse back to the same networks.
+		for i, formatted := range output {
+			network, err := ParseMaskedCIDR(formatted)
+			if err != nil || network.String() != testCase.Input[i] {
+				t.Errorf("ParseMaskedCIDR(%#v) expected: %s, got: %v, %v", formatted, testCase.Input[i], network, err)
+			}
+		}
+	}
+}
+
+// go test -v -run="TestExpandWildcard"
+
+func TestExpandWildcard(t *testing.T) {
+	type TestCase struct {
+		Addr     string
+		Wildcard string
+		Output   []string
+		Count    int64
+		Error    error
+	}
+
+	testCases := []TestCase{
+		{Addr: "10.0.0.300", Wildcard: "0.0.0.255", Error: &ParseError{Kind: KindIP, Input: "10.0.0.300", Index: -1}},
+		{Addr: "10.0.0.0", Wildcard: "0.0.0.x", Error: &ParseError{Kind: KindIP, Input: "0.0.0.x", Index: -1}},
+		{Addr: "10.0.0.0", Wildcard: "::ff", Error: ErrMismatchedFamily},
+		{Addr: "10.0.0.0", Wildcard: "0.0.0.0", Output: []string{"10.0.0.0/32"}, Count: 1},
+		{Addr: "10.0.0.0", Wildcard: "0.0.255.255", Output: []string{"10.0.0.0/16"}, Count: 1},
+		{Addr: "0.0.0.0", Wildcard: "255.255.255.255", Output: []string{"0.0.0.0/0"}, Count: 1},
+		{
+			Addr:     "10.0.0.1",
+			Wildcard: "0.0.1.2",
+			Output:   []string{"10.0.0.1/32", "10.0.0.3/32", "10.0.1.1/32", "10.0.1.3/32"},
+			Count:    4,
+		},
+		{
+			Addr:     "10.77.0.5",
+			Wildcard: "0.0.2.255",
+			Output:   []string{"10.77.0.0/24", "10.77.2.0/24"},
+			Count:    2,
+		},
+		{
+			Addr:     "10.0.0.0",
+			Wildcard: "128.0.0.255",
+			Output:   []string{"10.0.0.0/24", "138.0.0.0/24"},
+			Count:    2,
+		},
+		{Addr: "10.0.0.0", Wildcard: "0.255.255.0", Count: 1 << 16},
+		{Addr: "10.0.0.0", Wildcard: "0.255.0.255", Count: 1 << 8},
+		{Addr: "10.0.0.0", Wildcard: "1.255.255.0", Count: 1 << 17, Error: ErrTooManyBlocks},
+		{
+			Addr:     "2001:db8::",
+			Wildcard: "0:0:1::ffff",
+			Output:   []string{"2001:db8::/112", "2001:db8:1::/112"},
+			Count:    2,
+		},
+		{
+			Addr:     "2001:db8::1",
+			Wildcard: "8000::1:0:0:0:0",
+			Output:   []string{"2001:db8::1/128", "2001:db8:0:1::1/128", "a001:db8::1/128", "a001:db8:0:1::1/128"},
+			Count:    4,
+		},
+		{Addr: "2001:db8::", Wildcard: "::ffff:ffff:0", Count: 1 << 32, Error: ErrTooManyBlocks},
+	}
+
+	for _, testCase := range testCases {
+		output, count, err := ExpandWildcard(testCase.Addr, testCase.Wildcard)
+		if testCase.Count != 0 && (count == nil || count.Int64() != testCase.Count) {
+			t.Errorf("ExpandWildcard(%s, %s) expected count: %d, got: %v", testCase.Addr, testCase.Wildcard, testCase.Count, count)
+		}
+		if err != nil {
+			if !reflect.DeepEqual(testCase.Error, err) {
+				t.Errorf("ExpandWildcard(%s, %s) expected error: %#v, got: %#v", testCase.Addr, testCase.Wildcard, testCase.Error, err)
+			}
+			continue
+		}
+		if testCase.Error != nil {
+			t.Errorf("ExpandWildcard(%s, %s) expected error: %#v", testCase.Addr, testCase.Wildcard, testCase.Error)
+			continue
+		}
+		if int64(len(output)) != count.Int64() {
+			t.Errorf("ExpandWildcard(%s, %s) expected %d blocks, got: %d", testCase.Addr, testCase.Wildcard, count, len(output))
+		}
+		if testCase.Output != nil && !reflect.DeepEqual(testCase.Output, output) {
+			t.Errorf("ExpandWildcard(%s, %s) expected: %#v, got: %#v", testCase.Addr, testCase.Wildcard, testCase.Output, output)
+		}
+
+		// The list is already minimal.
+		if merged, err := MergeCIDRs(output); err != nil || len(merged) != len(output) {
+			t.Errorf("ExpandWildcard(%s, %s) not minimal, merged to %d blocks: %v", testCase.Addr, testCase.Wildcard, len(merged), err)
+		}
+	}
+}
+
+// go test -v -run="TestMaskedToCIDR"
+
+func TestMaskedToCIDR(t *testing.T) {
+	type TestCase struct {
+		Input  string
+		Output string
+		Error  error
+	}
+
+	testCases := []TestCase{
+		{Input: "10.0.0.0/33", Error: &ParseError{Kind: KindCIDR, Input: "10.0.0.0/33", Index: -1}},
+		{Input: "10.0.0.0 0.255.0.255", Error: &ParseError{Kind: KindCIDR, Input: "10.0.0.0 0.255.0.255", Index: -1, Err: ErrNonContiguousMask}},
+		{Input: " 10.1.2.3/8 ", Output: "10.1.2.3/8"},
+		{Input: "2001:db8::1/32", Output: "2001:db8::1/32"},
+		{Input: "10.1.2.3 255.0.0.0", Output: "10.1.2.3/8"},
+		{Input: "10.1.2.3 0.255.255.255", Output: "10.1.2.3/8"},
+		{Input: "192.0.2.1 0.0.0.0", Output: "192.0.2.1/32"},
+		{Input: "0.0.0.0 255.255.255.255", Output: "0.0.0.0/0"},
+	}
+
+	for _, testCase := range testCases {
+		output, err := MaskedToCIDR(testCase.Input)
+		if err != nil {
+			if !reflect.DeepEqual(testCase.Error, err) {
+				t.Errorf("MaskedToCIDR(%#v) expected error: %#v, got: %#v", testCase.Input, testCase.Error, err)
+			}
+			continue
+		}
+		if testCase.Error != nil {
+			t.Errorf("MaskedToCIDR(%#v) expected error: %#v, got: %s", testCase.Input, testCase.Error, output)
+			continue
+		}
+		if output != testCase.Output {
+			t.Errorf("MaskedToCIDR(%#v) expected: %s, got: %s", testCase.Input, testCase.Output, output)
+		}
+	}
+
+	// Strict merging rejects the host bits of every notation.
+	for _, input := range []string{"10.1.2.3/8", "10.1.2.3 255.0.0.0", "10.1.2.3 0.255.255.255"} {
+		cidr, err := MaskedToCIDR(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _, err = MergeCIDRsWithOptions([]string{cidr}, Options{Strict: true})
+		expected := &HostBitsError{Index: 0, Input: "10.1.2.3/8", Network: "10.0.0.0/8"}
+		if !reflect.DeepEqual(expected, err) {
+			t.Errorf("MergeCIDRsWithOptions(%#v) expected error: %#v, got: %#v", cidr, expected, err)
+		}
+	}
+}
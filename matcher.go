@@ -0,0 +1,114 @@
+package cidrman
+
+import (
+	"net"
+	"net/netip"
+	"sort"
+)
+
+// Matcher is an immutable, compiled set of IPv4 and IPv6 networks answering membership
+// tests in O(log n). It keeps the boundaries of the merged address ranges in sorted
+// arrays per address family and binary searches them.
+type Matcher struct {
+	first4 []uint32
+	last4  []uint32
+	first6 []uint128
+	last6  []uint128
+}
+
+// NewMatcher compiles a list of CIDR blocks into a Matcher.
+func NewMatcher(cidrs []string) (*Matcher, error) {
+	networks, err := parseCIDRs(cidrs)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMatcherFromIPNets(networks), nil
+}
+
+// NewMatcherFromIPNets compiles a list of IP networks into a Matcher.
+func NewMatcherFromIPNets(nets []*net.IPNet) *Matcher {
+	block4s, block6s := ipNets(nets).toBlocks()
+	return newMatcher(coalesce4(block4s), coalesce6(block6s))
+}
+
+// newMatcher returns a Matcher for lists of coalesced IPv4 and IPv6 blocks.
+func newMatcher(block4s cidrBlock4s, block6s cidrBlock6s) *Matcher {
+	m := Matcher{
+		first4: make([]uint32, len(block4s)),
+		last4:  make([]uint32, len(block4s)),
+		first6: make([]uint128, len(block6s)),
+		last6:  make([]uint128, len(block6s)),
+	}
+	for i, block := range block4s {
+		m.first4[i] = block.first
+		m.last4[i] = block.last
+	}
+	for i, block := range block6s {
+		m.first6[i] = block.first
+		m.last6[i] = block.last
+	}
+
+	return &m
+}
+
+// contains4 reports whether the IPv4 range first to last is within one of the blocks.
+func (m *Matcher) contains4(first, last uint32) bool {
+	i := sort.Search(len(m.last4), func(i int) bool { return m.last4[i] >= first })
+	return i < len(m.last4) && m.first4[i] <= first && m.last4[i] >= last
+}
+
+// contains6 reports whether the IPv6 range first to last is within one of the blocks.
+func (m *Matcher) contains6(first, last uint128) bool {
+	i := sort.Search(len(m.last6), func(i int) bool { return m.last6[i].cmp(first) >= 0 })
+	return i < len(m.last6) && m.first6[i].cmp(first) <= 0 && m.last6[i].cmp(last) >= 0
+}
+
+// Contains reports whether the IP address is within one of the networks.
+func (m *Matcher) Contains(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		addr := ipv4ToUInt32(ip4)
+		return m.contains4(addr, addr)
+	}
+	if ip6 := ip.To16(); ip6 != nil {
+		addr := ipv6ToUInt128(ip6)
+		return m.contains6(addr, addr)
+	}
+
+	return false
+}
+
+// ContainsAddr reports whether the address is within one of the networks. An IPv4-mapped IPv6 address,
+// such as one converted from a net.IP with netip.AddrFromSlice, is matched as the IPv4 address, as in Contains.
+func (m *Matcher) ContainsAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.Is4() {
+		a := addr4ToUInt32(addr)
+		return m.contains4(a, a)
+	}
+	if addr.Is6() {
+		a := addr6ToUInt128(addr)
+		return m.contains6(a, a)
+	}
+
+	return false
+}
+
+// ContainsPrefix reports whether the whole network is within the networks.
+func (m *Matcher) ContainsPrefix(network *net.IPNet) bool {
+	if network == nil {
+		return false
+	}
+
+	prefix, _ := network.Mask.Size()
+	if ip4 := network.IP.To4(); ip4 != nil {
+		first := network4(ipv4ToUInt32(ip4), uint(prefix))
+		return m.contains4(first, broadcast4(first, uint(prefix)))
+	}
+	if ip6 := network.IP.To16(); ip6 != nil {
+		first := network6(ipv6ToUInt128(ip6), uint(prefix))
+		return m.contains6(first, broadcast6(first, uint(prefix)))
+	}
+
+	return false
+}
@@ -0,0 +1,123 @@
+// go test -v -run="TestMatcher"
+
+package cidrman
+
+import (
+	"math/rand"
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestMatcher(t *testing.T) {
+	m, err := NewMatcher([]string{
+		"10.0.0.0/8",
+		"192.0.2.0/25",
+		"192.0.2.128/25",
+		"198.51.100.7/32",
+		"255.255.255.255/32",
+		"2001:db8::/32",
+		"fd00::1/128",
+	})
+	if err != nil {
+		t.Fatalf("NewMatcher failed: %s", err.Error())
+	}
+
+	type TestCase struct {
+		Input  string
+		Output bool
+	}
+
+	addrCases := []TestCase{
+		{Input: "0.0.0.0", Output: false},
+		{Input: "9.255.255.255", Output: false},
+		{Input: "10.0.0.0", Output: true},
+		{Input: "10.255.255.255", Output: true},
+		{Input: "11.0.0.0", Output: false},
+		{Input: "192.0.2.200", Output: true},
+		{Input: "198.51.100.6", Output: false},
+		{Input: "198.51.100.7", Output: true},
+		{Input: "198.51.100.8", Output: false},
+		{Input: "255.255.255.255", Output: true},
+		{Input: "::ffff:10.1.2.3", Output: true},
+		{Input: "2001:db8:ffff::1", Output: true},
+		{Input: "2001:db9::", Output: false},
+		{Input: "fd00::1", Output: true},
+		{Input: "fd00::2", Output: false},
+	}
+
+	for _, testCase := range addrCases {
+		if output := m.Contains(net.ParseIP(testCase.Input)); output != testCase.Output {
+			t.Errorf("Contains(%s) expected: %v, got: %v", testCase.Input, testCase.Output, output)
+		}
+		if output := m.ContainsAddr(netip.MustParseAddr(testCase.Input)); output != testCase.Output {
+			t.Errorf("ContainsAddr(%s) expected: %v, got: %v", testCase.Input, testCase.Output, output)
+		}
+		if addr, _ := netip.AddrFromSlice(net.ParseIP(testCase.Input)); m.ContainsAddr(addr) != testCase.Output {
+			t.Errorf("ContainsAddr(AddrFromSlice(%s)) expected: %v", testCase.Input, testCase.Output)
+		}
+	}
+	if m.Contains(nil) {
+		t.Errorf("Contains(nil) expected: false")
+	}
+
+	prefixCases := []TestCase{
+		{Input: "10.1.0.0/16", Output: true},
+		{Input: "10.0.0.0/7", Output: false},
+		{Input: "192.0.2.0/24", Output: true},
+		{Input: "192.0.2.0/23", Output: false},
+		{Input: "198.51.100.6/31", Output: false},
+		{Input: "2001:db8:1::/48", Output: true},
+		{Input: "2001:db8::/31", Output: false},
+	}
+
+	for _, testCase := range prefixCases {
+		_, network, _ := net.ParseCIDR(testCase.Input)
+		if output := m.ContainsPrefix(network); output != testCase.Output {
+			t.Errorf("ContainsPrefix(%s) expected: %v, got: %v", testCase.Input, testCase.Output, output)
+		}
+	}
+}
+
+// go test -run=NONE -bench="BenchmarkMatcher"
+
+// randomIPs returns n pseudo-random IPv4 addresses.
+func randomIPs(n int) []net.IP {
+	r := rand.New(rand.NewSource(2))
+	ips := make([]net.IP, n)
+	for i := range ips {
+		ips[i] = make(net.IP, net.IPv4len)
+		r.Read(ips[i])
+	}
+
+	return ips
+}
+
+func BenchmarkMatcherContains(b *testing.B) {
+	nets := randomIPNets(10000, net.IPv4len)
+	m := NewMatcherFromIPNets(nets)
+	ips := randomIPs(1024)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Contains(ips[i%len(ips)])
+	}
+}
+
+func BenchmarkMatcherNaive(b *testing.B) {
+	nets := randomIPNets(10000, net.IPv4len)
+	merged, _ := MergeIPNets(nets)
+	ips := randomIPs(1024)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ip := ips[i%len(ips)]
+		for _, n := range merged {
+			if n.Contains(ip) {
+				break
+			}
+		}
+	}
+}
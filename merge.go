@@ -4,7 +4,9 @@
 package cidrman
 
 import (
+	"errors"
 	"net"
+	"strings"
 )
 
 type ipNets []*net.IPNet
@@ -18,6 +20,37 @@ func (nets ipNets) toCIDRs() []string {
 	return cidrs
 }
 
+// toBlocks splits the networks into lists of IPv4 and IPv6 blocks.
+func (nets ipNets) toBlocks() (cidrBlock4s, cidrBlock6s) {
+	var block4s cidrBlock4s
+	var block6s cidrBlock6s
+	for _, net := range nets {
+		ip4 := net.IP.To4()
+		if ip4 != nil {
+			block4s = append(block4s, newBlock4(ip4, net.Mask))
+		} else {
+			ip6 := net.IP.To16()
+			block6s = append(block6s, newBlock6(ip6, net.Mask))
+		}
+	}
+
+	return block4s, block6s
+}
+
+// parseCIDRs parses a list of CIDR blocks into a list of IP networks.
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, &ParseError{Kind: KindCIDR, Input: cidr, Index: i}
+		}
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
 // MergeIPNets accepts a list of IP networks and merges them into the smallest possible list of IPNets.
 // It merges adjacent subnets where possible, those contained within others and removes any duplicates.
 func MergeIPNets(nets []*net.IPNet) ([]*net.IPNet, error) {
@@ -30,17 +63,7 @@ func MergeIPNets(nets []*net.IPNet) ([]*net.IPNet, error) {
 
 	// Split into IPv4 and IPv6 lists.
 	// Merge the list separately and then combine.
-	var block4s cidrBlock4s
-	var block6s cidrBlock6s
-	for _, net := range nets {
-		ip4 := net.IP.To4()
-		if ip4 != nil {
-			block4s = append(block4s, newBlock4(ip4, net.Mask))
-		} else {
-			ip6 := net.IP.To16()
-			block6s = append(block6s, newBlock6(ip6, net.Mask))
-		}
-	}
+	block4s, block6s := ipNets(nets).toBlocks()
 
 	merged4, err := merge4(block4s)
 	if err != nil {
@@ -65,13 +88,9 @@ func MergeCIDRs(cidrs []string) ([]string, error) {
 		return make([]string, 0), nil
 	}
 
-	var networks []*net.IPNet
-	for _, cidr := range cidrs {
-		_, network, err := net.ParseCIDR(cidr)
-		if err != nil {
-			return nil, err
-		}
-		networks = append(networks, network)
+	networks, err := parseCIDRs(cidrs)
+	if err != nil {
+		return nil, err
 	}
 	mergedNets, err := MergeIPNets(networks)
 	if err != nil {
@@ -80,3 +99,69 @@ func MergeCIDRs(cidrs []string) ([]string, error) {
 
 	return ipNets(mergedNets).toCIDRs(), nil
 }
+
+// MergeMixed accepts a list of CIDR blocks, single IP addresses and IP ranges in any combination
+// and merges them into the smallest possible list of CIDRs.
+// IP ranges are accepted in the notations supported by ParseRange.
+func MergeMixed(entries []string) ([]string, error) {
+	if entries == nil {
+		return nil, nil
+	}
+	if len(entries) == 0 {
+		return make([]string, 0), nil
+	}
+
+	var block4s cidrBlock4s
+	var block6s cidrBlock6s
+	for i, entry := range entries {
+		entry = strings.TrimSpace(entry)
+
+		var start, end net.IP
+		switch {
+		case strings.Contains(entry, "/"):
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, &ParseError{Kind: KindCIDR, Input: entry, Index: i}
+			}
+			start = network.IP
+			end = make(net.IP, len(network.IP))
+			for i := range network.IP {
+				end[i] = network.IP[i] | ^network.Mask[i]
+			}
+		case strings.Contains(entry, "-"):
+			var err error
+			start, end, err = ParseRange(entry)
+			if err != nil {
+				var parseErr *ParseError
+				if errors.As(err, &parseErr) {
+					parseErr.Index = i
+				}
+				return nil, err
+			}
+		default:
+			start = net.ParseIP(entry)
+			if start == nil {
+				return nil, &ParseError{Kind: KindIP, Input: entry, Index: i}
+			}
+			end = start
+		}
+
+		if start4 := start.To4(); start4 != nil {
+			block4s = append(block4s, &cidrBlock4{first: ipv4ToUInt32(start4), last: ipv4ToUInt32(end.To4())})
+		} else {
+			block6s = append(block6s, &cidrBlock6{first: ipv6ToUInt128(start.To16()), last: ipv6ToUInt128(end.To16())})
+		}
+	}
+
+	merged4, err := merge4(block4s)
+	if err != nil {
+		return nil, err
+	}
+
+	merged6, err := merge6(block6s)
+	if err != nil {
+		return nil, err
+	}
+
+	return ipNets(append(merged4, merged6...)).toCIDRs(), nil
+}
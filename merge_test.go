@@ -3,6 +3,8 @@
 package cidrman
 
 import (
+	"math/rand"
+	"net"
 	"reflect"
 	"testing"
 )
@@ -351,6 +353,16 @@ func TestMergeCIDRs(t *testing.T) {
 			},
 			Error: false,
 		},
+		{
+			Input: []string{
+				"255.255.255.255/32",
+				"255.0.0.0/8",
+			},
+			Output: []string{
+				"255.0.0.0/8",
+			},
+			Error: false,
+		},
 		// Mixed IPv4 and IPv6 tests
 		{
 			Input: []string{
@@ -380,3 +392,139 @@ func TestMergeCIDRs(t *testing.T) {
 		}
 	}
 }
+
+func TestMergeMixed(t *testing.T) {
+	type TestCase struct {
+		Input  []string
+		Output []string
+		Error  bool
+	}
+
+	testCases := []TestCase{
+		{
+			Input:  nil,
+			Output: nil,
+			Error:  false,
+		},
+		{
+			Input:  []string{},
+			Output: []string{},
+			Error:  false,
+		},
+		{
+			Input:  []string{"192.0.2.0/33"},
+			Output: nil,
+			Error:  true,
+		},
+		{
+			Input:  []string{"192.0.2.10-1"},
+			Output: nil,
+			Error:  true,
+		},
+		{
+			Input:  []string{"192.0.2.300"},
+			Output: nil,
+			Error:  true,
+		},
+		{
+			Input: []string{
+				"192.0.2.0/25",
+				"192.0.2.128-192.0.2.254",
+				"192.0.2.255",
+			},
+			Output: []string{
+				"192.0.2.0/24",
+			},
+			Error: false,
+		},
+		{
+			Input: []string{
+				" 10.0.0.1-50 ",
+				"10.0.0.0",
+			},
+			Output: []string{
+				"10.0.0.0/27",
+				"10.0.0.32/28",
+				"10.0.0.48/31",
+				"10.0.0.50/32",
+			},
+			Error: false,
+		},
+		{
+			Input: []string{
+				"2001:db8::-ff",
+				"2001:db8::100/120",
+				"192.0.2.1",
+			},
+			Output: []string{
+				"192.0.2.1/32",
+				"2001:db8::/119",
+			},
+			Error: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		output, err := MergeMixed(testCase.Input)
+		if err != nil {
+			if !testCase.Error {
+				t.Errorf("MergeMixed(%#v) failed: %s", testCase.Input, err.Error())
+			}
+			continue
+		}
+		if !reflect.DeepEqual(testCase.Output, output) {
+			t.Errorf("MergeMixed(%#v) expected: %#v, got: %#v", testCase.Input, testCase.Output, output)
+		}
+	}
+}
+
+// go test -run=NONE -bench="BenchmarkMergeIPNets"
+
+// benchmarkPrefixes is the number of prefixes merged per benchmark iteration,
+// roughly the size of a full IPv6 routing table.
+const benchmarkPrefixes = 200000
+
+// randomIPNets returns n pseudo-random networks of the given address length,
+// with prefixes between 8 and 32 for IPv4 and between 16 and 64 for IPv6.
+func randomIPNets(n int, length int) []*net.IPNet {
+	r := rand.New(rand.NewSource(1))
+	nets := make([]*net.IPNet, n)
+	for i := range nets {
+		ip := make(net.IP, length)
+		r.Read(ip)
+
+		var mask net.IPMask
+		if length == net.IPv4len {
+			mask = net.CIDRMask(8+r.Intn(25), 8*net.IPv4len)
+		} else {
+			mask = net.CIDRMask(16+r.Intn(49), 8*net.IPv6len)
+		}
+		nets[i] = &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+	}
+
+	return nets
+}
+
+func BenchmarkMergeIPNets4(b *testing.B) {
+	nets := randomIPNets(benchmarkPrefixes, net.IPv4len)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := MergeIPNets(nets); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMergeIPNets6(b *testing.B) {
+	nets := randomIPNets(benchmarkPrefixes, net.IPv6len)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := MergeIPNets(nets); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
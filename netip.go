@@ -0,0 +1,151 @@
+package cidrman
+
+import (
+	"net/netip"
+)
+
+// prefixesToBlocks splits the prefixes into lists of IPv4 and IPv6 blocks.
+func prefixesToBlocks(prefixes []netip.Prefix) (cidrBlock4s, cidrBlock6s, error) {
+	var block4s cidrBlock4s
+	var block6s cidrBlock6s
+	for i, prefix := range prefixes {
+		if !prefix.IsValid() {
+			return nil, nil, &ParseError{Kind: KindPrefix, Input: prefix.String(), Index: i}
+		}
+
+		prefix = prefix.Masked()
+		if prefix.Addr().Is4() {
+			first := addr4ToUInt32(prefix.Addr())
+			block4s = append(block4s, &cidrBlock4{first: first, last: broadcast4(first, uint(prefix.Bits()))})
+		} else {
+			first := addr6ToUInt128(prefix.Addr())
+			block6s = append(block6s, &cidrBlock6{first: first, last: broadcast6(first, uint(prefix.Bits()))})
+		}
+	}
+
+	return block4s, block6s, nil
+}
+
+// blocksToPrefixes computes the prefixes covering the IPv4 and IPv6 blocks, IPv4 prefixes first.
+func blocksToPrefixes(block4s cidrBlock4s, block6s cidrBlock6s) ([]netip.Prefix, error) {
+	prefixes4, err := block4s.toPrefixes()
+	if err != nil {
+		return nil, err
+	}
+
+	prefixes6, err := block6s.toPrefixes()
+	if err != nil {
+		return nil, err
+	}
+
+	prefixes := append(prefixes4, prefixes6...)
+	if prefixes == nil {
+		return make([]netip.Prefix, 0), nil
+	}
+	return prefixes, nil
+}
+
+// MergePrefixes accepts a list of prefixes and merges them into the smallest possible list of prefixes.
+// It merges adjacent subnets where possible, those contained within others and removes any duplicates.
+func MergePrefixes(prefixes []netip.Prefix) ([]netip.Prefix, error) {
+	if prefixes == nil {
+		return nil, nil
+	}
+
+	block4s, block6s, err := prefixesToBlocks(prefixes)
+	if err != nil {
+		return nil, err
+	}
+
+	return blocksToPrefixes(coalesce4(block4s), coalesce6(block6s))
+}
+
+// RemovePrefixes accepts a list of base prefixes and a list of prefixes to exclude from them.
+// It returns the smallest possible list of prefixes covering the base prefixes minus the excluded ones.
+func RemovePrefixes(base, exclude []netip.Prefix) ([]netip.Prefix, error) {
+	if base == nil {
+		return nil, nil
+	}
+
+	base4s, base6s, err := prefixesToBlocks(base)
+	if err != nil {
+		return nil, err
+	}
+	exclude4s, exclude6s, err := prefixesToBlocks(exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	return blocksToPrefixes(remove4(base4s, exclude4s), remove6(base6s, exclude6s))
+}
+
+// RangeToPrefixes accepts an arbitrary start and end address and returns a list of
+// prefixes that fit exactly between the boundaries of the two with no overlap.
+func RangeToPrefixes(start, end netip.Addr) ([]netip.Prefix, error) {
+	if !start.IsValid() {
+		return nil, &ParseError{Kind: KindIP, Input: start.String(), Index: -1}
+	}
+	if !end.IsValid() {
+		return nil, &ParseError{Kind: KindIP, Input: end.String(), Index: -1}
+	}
+	if start.Is4() != end.Is4() {
+		return nil, ErrMismatchedFamily
+	}
+	if end.Less(start) {
+		return nil, ErrRangeReversed
+	}
+
+	var prefixes []netip.Prefix
+	if start.Is4() {
+		if err := splitRange4(0, 0, addr4ToUInt32(start), addr4ToUInt32(end), 0, appendPrefix4(&prefixes)); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := splitRange6(uint128{}, 0, addr6ToUInt128(start), addr6ToUInt128(end), 0, appendPrefix6(&prefixes)); err != nil {
+			return nil, err
+		}
+	}
+
+	return prefixes, nil
+}
+
+// SubnetsPrefix divides up a prefix into smaller subnets based on a specified prefix length.
+// It returns ErrTooManyBlocks if there would be more than MaxSubnets subnets.
+func SubnetsPrefix(network netip.Prefix, prefix int) ([]netip.Prefix, error) {
+	if !network.IsValid() {
+		return nil, &ParseError{Kind: KindPrefix, Input: network.String(), Index: -1}
+	}
+	if prefix < 0 {
+		return nil, &PrefixLengthError{Prefix: prefix, Network: network.Masked().String(), Min: network.Bits(), Max: network.Addr().BitLen()}
+	}
+
+	var subnets []netip.Prefix
+	if network.Addr().Is4() {
+		if err := subnets4(addr4ToUInt32(network.Addr()), uint(network.Bits()), uint(prefix), appendPrefix4(&subnets)); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := subnets6(addr6ToUInt128(network.Addr()), uint(network.Bits()), uint(prefix), appendPrefix6(&subnets)); err != nil {
+			return nil, err
+		}
+	}
+
+	return subnets, nil
+}
+
+// NewIPSetFromPrefixes returns the set of addresses covered by a list of prefixes.
+func NewIPSetFromPrefixes(prefixes []netip.Prefix) (*IPSet, error) {
+	block4s, block6s, err := prefixesToBlocks(prefixes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IPSet{block4s: coalesce4(block4s), block6s: coalesce6(block6s)}, nil
+}
+
+// Prefixes returns the smallest possible list of prefixes covering the set, IPv4 prefixes first.
+func (s *IPSet) Prefixes() []netip.Prefix {
+	// The blocks are within the address space, so splitting them cannot fail.
+	prefixes, _ := blocksToPrefixes(s.block4s, s.block6s)
+	return prefixes
+}
@@ -0,0 +1,222 @@
+// go test -v -run="TestPrefixes"
+
+package cidrman
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+// parsePrefixes parses a list of CIDR blocks into prefixes, keeping nil as nil.
+func parsePrefixes(cidrs []string) []netip.Prefix {
+	if cidrs == nil {
+		return nil
+	}
+
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefixes = append(prefixes, netip.MustParsePrefix(cidr))
+	}
+
+	return prefixes
+}
+
+func TestMergePrefixes(t *testing.T) {
+	type TestCase struct {
+		Input  []string
+		Output []string
+	}
+
+	testCases := []TestCase{
+		{
+			Input:  nil,
+			Output: nil,
+		},
+		{
+			Input:  []string{},
+			Output: []string{},
+		},
+		{
+			Input: []string{
+				"192.0.128.0/24",
+				"192.0.129.0/24",
+			},
+			Output: []string{
+				"192.0.128.0/23",
+			},
+		},
+		{
+			Input: []string{
+				"192.0.2.77/24",
+				"192.0.2.0/25",
+			},
+			Output: []string{
+				"192.0.2.0/24",
+			},
+		},
+		{
+			Input: []string{
+				"2001:db8:0:2::/64",
+				"2001:db8:0:3::/64",
+				"192.0.129.0/24",
+				"192.0.128.0/24",
+			},
+			Output: []string{
+				"192.0.128.0/23",
+				"2001:db8:0:2::/63",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		output, err := MergePrefixes(parsePrefixes(testCase.Input))
+		if err != nil {
+			t.Errorf("MergePrefixes(%#v) failed: %s", testCase.Input, err.Error())
+			continue
+		}
+		if expected := parsePrefixes(testCase.Output); !reflect.DeepEqual(expected, output) {
+			t.Errorf("MergePrefixes(%#v) expected: %v, got: %v", testCase.Input, expected, output)
+		}
+	}
+
+	if _, err := MergePrefixes([]netip.Prefix{{}}); err == nil {
+		t.Errorf("MergePrefixes with an invalid prefix expected error")
+	}
+}
+
+func TestRemovePrefixes(t *testing.T) {
+	base := parsePrefixes([]string{"192.0.2.0/24", "2001:db8::/32"})
+	exclude := parsePrefixes([]string{"192.0.2.128/25", "2001:db8::/33"})
+	expected := parsePrefixes([]string{"192.0.2.0/25", "2001:db8:8000::/33"})
+
+	output, err := RemovePrefixes(base, exclude)
+	if err != nil {
+		t.Fatalf("RemovePrefixes(%v, %v) failed: %s", base, exclude, err.Error())
+	}
+	if !reflect.DeepEqual(expected, output) {
+		t.Errorf("RemovePrefixes(%v, %v) expected: %v, got: %v", base, exclude, expected, output)
+	}
+}
+
+func TestRangeToPrefixes(t *testing.T) {
+	type TestCase struct {
+		Lo     string
+		Hi     string
+		Output []string
+		Error  bool
+	}
+
+	testCases := []TestCase{
+		{
+			Lo:     "192.168.1.12",
+			Hi:     "192.168.1.11",
+			Output: nil,
+			Error:  true,
+		},
+		{
+			Lo:     "0.0.0.1",
+			Hi:     "2001:db8::1",
+			Output: nil,
+			Error:  true,
+		},
+		{
+			Lo: "192.168.1.1",
+			Hi: "192.168.1.12",
+			Output: []string{
+				"192.168.1.1/32",
+				"192.168.1.2/31",
+				"192.168.1.4/30",
+				"192.168.1.8/30",
+				"192.168.1.12/32",
+			},
+			Error: false,
+		},
+		{
+			Lo: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffd",
+			Hi: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff",
+			Output: []string{
+				"ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffd/128",
+				"ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe/127",
+			},
+			Error: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		output, err := RangeToPrefixes(netip.MustParseAddr(testCase.Lo), netip.MustParseAddr(testCase.Hi))
+		if err != nil {
+			if !testCase.Error {
+				t.Errorf("RangeToPrefixes(%s, %s) failed: %s", testCase.Lo, testCase.Hi, err.Error())
+			}
+			continue
+		}
+		if expected := parsePrefixes(testCase.Output); !reflect.DeepEqual(expected, output) {
+			t.Errorf("RangeToPrefixes(%s, %s) expected: %v, got: %v", testCase.Lo, testCase.Hi, expected, output)
+		}
+	}
+}
+
+func TestSubnetsPrefix(t *testing.T) {
+	type TestCase struct {
+		Input  string
+		Prefix int
+		Output []string
+		Error  bool
+	}
+
+	testCases := []TestCase{
+		{
+			Input:  "192.0.2.0/24",
+			Prefix: 23,
+			Output: nil,
+			Error:  true,
+		},
+		{
+			Input:  "192.0.2.0/24",
+			Prefix: 26,
+			Output: []string{
+				"192.0.2.0/26",
+				"192.0.2.64/26",
+				"192.0.2.128/26",
+				"192.0.2.192/26",
+			},
+			Error: false,
+		},
+		{
+			Input:  "2001:db8::/32",
+			Prefix: 33,
+			Output: []string{
+				"2001:db8::/33",
+				"2001:db8:8000::/33",
+			},
+			Error: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		output, err := SubnetsPrefix(netip.MustParsePrefix(testCase.Input), testCase.Prefix)
+		if err != nil {
+			if !testCase.Error {
+				t.Errorf("SubnetsPrefix(%s, %d) failed: %s", testCase.Input, testCase.Prefix, err.Error())
+			}
+			continue
+		}
+		if expected := parsePrefixes(testCase.Output); !reflect.DeepEqual(expected, output) {
+			t.Errorf("SubnetsPrefix(%s, %d) expected: %v, got: %v", testCase.Input, testCase.Prefix, expected, output)
+		}
+	}
+}
+
+func TestIPSetPrefixes(t *testing.T) {
+	input := parsePrefixes([]string{"192.0.2.0/25", "192.0.2.128/25", "2001:db8::/32"})
+	expected := parsePrefixes([]string{"192.0.2.0/24", "2001:db8::/32"})
+
+	set, err := NewIPSetFromPrefixes(input)
+	if err != nil {
+		t.Fatalf("NewIPSetFromPrefixes(%v) failed: %s", input, err.Error())
+	}
+	if output := set.Prefixes(); !reflect.DeepEqual(expected, output) {
+		t.Errorf("Prefixes(%v) expected: %v, got: %v", input, expected, output)
+	}
+}
@@ -0,0 +1,176 @@
+package cidrman
+
+import (
+	"fmt"
+	"net"
+)
+
+// Options configures MergeCIDRsWithOptions and IPRangeToCIDRsWithOptions.
+type Options struct {
+	// Strict rejects CIDR blocks with host bits set, such as 10.1.2.3/8, with a *HostBitsError
+	// instead of masking them down to the network address. It does not apply to ranges.
+	Strict bool
+
+	// MinPrefix4 and MinPrefix6 are the shortest prefix lengths the merged IPv4 and IPv6 blocks may have.
+	// Aggregation stops at that boundary and emits several blocks instead, so 10.0.0.0/15 merged with
+	// MinPrefix4 16 gives 10.0.0.0/16 and 10.1.0.0/16. Input blocks shorter than the minimum are split,
+	// and ErrTooManyBlocks is returned if that would add more than MaxSubnets blocks. Zero means no limit.
+	MinPrefix4 int
+	MinPrefix6 int
+
+	// MaxPrefix4 and MaxPrefix6 are the longest prefix lengths the IPv4 and IPv6 output may have.
+	// Input needing a longer prefix is rejected with a *PrefixTooLongError, or expanded to the
+	// covering blocks of the maximum length if Expand is set. Zero means no limit.
+	MaxPrefix4 int
+	MaxPrefix6 int
+
+	// Expand widens input longer than the maximum prefix length, such as 192.0.2.128/25 with
+	// MaxPrefix4 24, to the covering block 192.0.2.0/24 instead of rejecting it.
+	Expand bool
+}
+
+// validate checks the prefix length limits of the options.
+func (o Options) validate() error {
+	if err := checkPrefixLimits(o.MinPrefix4, o.MaxPrefix4, widthUInt32); err != nil {
+		return err
+	}
+	return checkPrefixLimits(o.MinPrefix6, o.MaxPrefix6, widthUInt128)
+}
+
+// checkPrefixLimits checks a minimum and maximum prefix length for an address family of the specified width.
+func checkPrefixLimits(min, max, width int) error {
+	if max < 0 || max > width {
+		return &PrefixLengthError{Prefix: max, Min: 0, Max: width}
+	}
+	if max == 0 {
+		max = width
+	}
+	if min < 0 || min > max {
+		return &PrefixLengthError{Prefix: min, Min: 0, Max: max}
+	}
+	return nil
+}
+
+// maxPrefix returns the maximum prefix length for IP networks with the specified mask width, 0 if none.
+func (o Options) maxPrefix(bits int) int {
+	if bits == widthUInt32 {
+		return o.MaxPrefix4
+	}
+	return o.MaxPrefix6
+}
+
+// HostBitsError is returned in strict mode for a CIDR block whose address is not the network address.
+type HostBitsError struct {
+	// Index is the position of the CIDR block in the input, or -1 when it is not part of a list.
+	Index int
+	// Input is the CIDR block as given.
+	Input string
+	// Network is the CIDR block with the host bits masked.
+	Network string
+}
+
+func (e *HostBitsError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("CIDR block %s has host bits set, expected %s", e.Input, e.Network)
+	}
+	return fmt.Sprintf("CIDR block %s at index %d has host bits set, expected %s", e.Input, e.Index, e.Network)
+}
+
+// PrefixTooLongError is returned for input needing a prefix longer than the maximum prefix length
+// when it is not expanded.
+type PrefixTooLongError struct {
+	// Index is the position of the CIDR block in the input, or -1 for a range.
+	Index int
+	// Input is the CIDR block or range as given.
+	Input string
+	// Prefix is the longest prefix length the input needs.
+	Prefix int
+	// Max is the maximum prefix length allowed.
+	Max int
+}
+
+func (e *PrefixTooLongError) Error() string {
+	if e.Index >= 0 {
+		return fmt.Sprintf("CIDR block %s at index %d has prefix length %d, longer than the maximum %d", e.Input, e.Index, e.Prefix, e.Max)
+	}
+	return fmt.Sprintf("Range %s needs prefix length %d, longer than the maximum %d", e.Input, e.Prefix, e.Max)
+}
+
+// Normalization records a CIDR block whose host bits were masked in lenient mode.
+type Normalization struct {
+	// Index is the position of the CIDR block in the input.
+	Index int
+	// Input is the CIDR block as given.
+	Input string
+	// Network is the CIDR block with the host bits masked.
+	Network string
+}
+
+// parseCIDRsWithOptions parses a list of CIDR blocks into a list of IP networks,
+// rejecting or recording those with host bits set and rejecting or expanding those
+// longer than the maximum prefix length.
+func parseCIDRsWithOptions(cidrs []string, opts Options) ([]*net.IPNet, []Normalization, error) {
+	var networks []*net.IPNet
+	var normalizations []Normalization
+	for i, cidr := range cidrs {
+		ip, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, nil, &ParseError{Kind: KindCIDR, Input: cidr, Index: i}
+		}
+
+		if !ip.Equal(network.IP) {
+			if opts.Strict {
+				return nil, nil, &HostBitsError{Index: i, Input: cidr, Network: network.String()}
+			}
+			normalizations = append(normalizations, Normalization{Index: i, Input: cidr, Network: network.String()})
+		}
+
+		ones, bits := network.Mask.Size()
+		if max := opts.maxPrefix(bits); max != 0 && ones > max {
+			if !opts.Expand {
+				return nil, nil, &PrefixTooLongError{Index: i, Input: cidr, Prefix: ones, Max: max}
+			}
+			mask := net.CIDRMask(max, bits)
+			network = &net.IPNet{IP: network.IP.Mask(mask), Mask: mask}
+		}
+		networks = append(networks, network)
+	}
+
+	return networks, normalizations, nil
+}
+
+// MergeCIDRsWithOptions accepts a list of CIDR blocks and merges them into the smallest possible list of CIDRs.
+// In strict mode a CIDR block with host bits set is rejected with a *HostBitsError. Otherwise it is masked
+// down to its network address and reported in the list of normalizations.
+// No merged block is shorter than the minimum or longer than the maximum prefix length for its family.
+func MergeCIDRsWithOptions(cidrs []string, opts Options) ([]string, []Normalization, error) {
+	if err := opts.validate(); err != nil {
+		return nil, nil, err
+	}
+	if cidrs == nil {
+		return nil, nil, nil
+	}
+	if len(cidrs) == 0 {
+		return make([]string, 0), nil, nil
+	}
+
+	networks, normalizations, err := parseCIDRsWithOptions(cidrs, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	block4s, block6s := ipNets(networks).toBlocks()
+
+	merged4, err := coalesce4(block4s).toIPNetsMinPrefix(uint(opts.MinPrefix4))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	merged6, err := coalesce6(block6s).toIPNetsMinPrefix(uint(opts.MinPrefix6))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	merged := append(merged4, merged6...)
+	return ipNets(merged).toCIDRs(), normalizations, nil
+}
@@ -0,0 +1,316 @@
+// go test -v -run="TestMergeCIDRsWithOptions"
+
+package cidrman
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeCIDRsWithOptions(t *testing.T) {
+	type TestCase struct {
+		Input          []string
+		Options        Options
+		Output         []string
+		Normalizations []Normalization
+		Error          error
+	}
+
+	testCases := []TestCase{
+		{
+			Input:   nil,
+			Options: Options{Strict: true},
+			Output:  nil,
+		},
+		{
+			Input:   []string{},
+			Options: Options{Strict: true},
+			Output:  []string{},
+		},
+		{
+			Input: []string{
+				"10.0.0.0/8",
+				"2001:db8::/32",
+			},
+			Options: Options{Strict: true},
+			Output: []string{
+				"10.0.0.0/8",
+				"2001:db8::/32",
+			},
+		},
+		{
+			Input: []string{
+				"10.0.0.0/8",
+				"10.1.2.3/8",
+			},
+			Options: Options{Strict: true},
+			Error:   &HostBitsError{Index: 1, Input: "10.1.2.3/8", Network: "10.0.0.0/8"},
+		},
+		{
+			Input: []string{
+				"2001:db8::1/32",
+			},
+			Options: Options{Strict: true},
+			Error:   &HostBitsError{Index: 0, Input: "2001:db8::1/32", Network: "2001:db8::/32"},
+		},
+		{
+			Input: []string{
+				"10.0.0.0/8",
+				"10.1.2.3/8",
+				"192.0.2.1/24",
+			},
+			Options: Options{},
+			Output: []string{
+				"10.0.0.0/8",
+				"192.0.2.0/24",
+			},
+			Normalizations: []Normalization{
+				{Index: 1, Input: "10.1.2.3/8", Network: "10.0.0.0/8"},
+				{Index: 2, Input: "192.0.2.1/24", Network: "192.0.2.0/24"},
+			},
+		},
+		{
+			Input: []string{
+				"10.0.0.0/16",
+				"10.1.0.0/16",
+				"10.2.0.0/15",
+				"192.0.2.0/25",
+				"192.0.2.128/25",
+			},
+			Options: Options{MinPrefix4: 16},
+			Output: []string{
+				"10.0.0.0/16",
+				"10.1.0.0/16",
+				"10.2.0.0/16",
+				"10.3.0.0/16",
+				"192.0.2.0/24",
+			},
+		},
+		{
+			Input: []string{
+				"0.0.0.0/1",
+				"128.0.0.0/1",
+			},
+			Options: Options{MinPrefix4: 2},
+			Output: []string{
+				"0.0.0.0/2",
+				"64.0.0.0/2",
+				"128.0.0.0/2",
+				"192.0.0.0/2",
+			},
+		},
+		{
+			Input: []string{
+				"10.0.0.0/8",
+				"2001:db8::/31",
+				"2001:dba::/32",
+			},
+			Options: Options{MinPrefix6: 32},
+			Output: []string{
+				"10.0.0.0/8",
+				"2001:db8::/32",
+				"2001:db9::/32",
+				"2001:dba::/32",
+			},
+		},
+		{
+			Input: []string{
+				"10.0.0.0/8",
+			},
+			Options: Options{MinPrefix4: 33},
+			Error:   &PrefixLengthError{Prefix: 33, Min: 0, Max: 32},
+		},
+		{
+			Input: []string{
+				"2000::/3",
+			},
+			Options: Options{MinPrefix6: 32},
+			Error:   ErrTooManyBlocks,
+		},
+		{
+			Input: []string{
+				"::/0",
+			},
+			Options: Options{MinPrefix6: 128},
+			Error:   ErrTooManyBlocks,
+		},
+		{
+			Input: []string{
+				"0.0.0.0/0",
+			},
+			Options: Options{MinPrefix4: 32},
+			Error:   ErrTooManyBlocks,
+		},
+		{
+			Input: []string{
+				"10.0.0.0/8",
+			},
+			Options: Options{MinPrefix6: -1},
+			Error:   &PrefixLengthError{Prefix: -1, Min: 0, Max: 128},
+		},
+		{
+			Input: []string{
+				"10.0.0.0/8",
+			},
+			Options: Options{MinPrefix4: 25, MaxPrefix4: 24},
+			Error:   &PrefixLengthError{Prefix: 25, Min: 0, Max: 24},
+		},
+		{
+			Input: []string{
+				"2001:db8::/32",
+			},
+			Options: Options{MaxPrefix6: 129},
+			Error:   &PrefixLengthError{Prefix: 129, Min: 0, Max: 128},
+		},
+		{
+			Input: []string{
+				"10.0.0.0/8",
+				"192.0.2.128/25",
+			},
+			Options: Options{MaxPrefix4: 24},
+			Error:   &PrefixTooLongError{Index: 1, Input: "192.0.2.128/25", Prefix: 25, Max: 24},
+		},
+		{
+			Input: []string{
+				"10.0.0.0/8",
+				"192.0.2.128/25",
+				"192.0.3.7/32",
+				"2001:db8::1/128",
+			},
+			Options: Options{MaxPrefix4: 24, Expand: true},
+			Output: []string{
+				"10.0.0.0/8",
+				"192.0.2.0/23",
+				"2001:db8::1/128",
+			},
+		},
+		{
+			Input: []string{
+				"2001:db8:0:1::/64",
+				"2001:db8::1/128",
+			},
+			Options: Options{MaxPrefix6: 48, Expand: true},
+			Output: []string{
+				"2001:db8::/48",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		output, normalizations, err := MergeCIDRsWithOptions(testCase.Input, testCase.Options)
+		if err != nil {
+			if !reflect.DeepEqual(testCase.Error, err) {
+				t.Errorf("MergeCIDRsWithOptions(%#v, %#v) expected error: %#v, got: %#v", testCase.Input, testCase.Options, testCase.Error, err)
+			}
+			continue
+		}
+		if testCase.Error != nil {
+			t.Errorf("MergeCIDRsWithOptions(%#v, %#v) expected error: %#v", testCase.Input, testCase.Options, testCase.Error)
+			continue
+		}
+		if !reflect.DeepEqual(testCase.Output, output) {
+			t.Errorf("MergeCIDRsWithOptions(%#v, %#v) expected: %#v, got: %#v", testCase.Input, testCase.Options, testCase.Output, output)
+		}
+		if !reflect.DeepEqual(testCase.Normalizations, normalizations) {
+			t.Errorf("MergeCIDRsWithOptions(%#v, %#v) expected normalizations: %#v, got: %#v", testCase.Input, testCase.Options, testCase.Normalizations, normalizations)
+		}
+	}
+}
+
+// go test -v -run="TestIPRangeToCIDRsWithOptions"
+
+func TestIPRangeToCIDRsWithOptions(t *testing.T) {
+	type TestCase struct {
+		Start   string
+		End     string
+		Options Options
+		Output  []string
+		Error   error
+	}
+
+	testCases := []TestCase{
+		{
+			Start:   "10.0.0.0",
+			End:     "10.3.255.255",
+			Options: Options{MinPrefix4: 16},
+			Output: []string{
+				"10.0.0.0/16",
+				"10.1.0.0/16",
+				"10.2.0.0/16",
+				"10.3.0.0/16",
+			},
+		},
+		{
+			Start:   "192.0.2.0",
+			End:     "192.0.3.255",
+			Options: Options{MaxPrefix4: 24},
+			Output: []string{
+				"192.0.2.0/23",
+			},
+		},
+		{
+			Start:   "192.0.2.1",
+			End:     "192.0.3.255",
+			Options: Options{MaxPrefix4: 24},
+			Error:   &PrefixTooLongError{Index: -1, Input: "192.0.2.1-192.0.3.255", Prefix: 32, Max: 24},
+		},
+		{
+			Start:   "192.0.2.1",
+			End:     "192.0.4.10",
+			Options: Options{MaxPrefix4: 24, Expand: true},
+			Output: []string{
+				"192.0.2.0/23",
+				"192.0.4.0/24",
+			},
+		},
+		{
+			Start:   "2001:db8::1",
+			End:     "2001:db8:1::ffff",
+			Options: Options{MaxPrefix6: 48, Expand: true},
+			Output: []string{
+				"2001:db8::/47",
+			},
+		},
+		{
+			Start:   "2001:db8::",
+			End:     "2001:db8::1",
+			Options: Options{MaxPrefix6: 64},
+			Error:   &PrefixTooLongError{Index: -1, Input: "2001:db8::-2001:db8::1", Prefix: 127, Max: 64},
+		},
+		{
+			Start:   "192.0.2.1",
+			End:     "192.0.2.5",
+			Options: Options{MaxPrefix4: 33},
+			Error:   &PrefixLengthError{Prefix: 33, Min: 0, Max: 32},
+		},
+		{
+			Start:   "0.0.0.0",
+			End:     "255.255.255.255",
+			Options: Options{MinPrefix4: 32},
+			Error:   ErrTooManyBlocks,
+		},
+		{
+			Start:   "::",
+			End:     "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff",
+			Options: Options{MinPrefix6: 64},
+			Error:   ErrTooManyBlocks,
+		},
+	}
+
+	for _, testCase := range testCases {
+		output, err := IPRangeToCIDRsWithOptions(testCase.Start, testCase.End, testCase.Options)
+		if err != nil {
+			if !reflect.DeepEqual(testCase.Error, err) {
+				t.Errorf("IPRangeToCIDRsWithOptions(%s, %s, %#v) expected error: %#v, got: %#v", testCase.Start, testCase.End, testCase.Options, testCase.Error, err)
+			}
+			continue
+		}
+		if testCase.Error != nil {
+			t.Errorf("IPRangeToCIDRsWithOptions(%s, %s, %#v) expected error: %#v", testCase.Start, testCase.End, testCase.Options, testCase.Error)
+			continue
+		}
+		if !reflect.DeepEqual(testCase.Output, output) {
+			t.Errorf("IPRangeToCIDRsWithOptions(%s, %s, %#v) expected: %#v, got: %#v", testCase.Start, testCase.End, testCase.Options, testCase.Output, output)
+		}
+	}
+}
@@ -4,73 +4,212 @@
 package cidrman
 
 import (
-	"errors"
-	"fmt"
-	"math/big"
+	"bytes"
 	"net"
+	"strconv"
+	"strings"
 )
 
 // IPRangeToIPNets accepts an arbitrary start and end IP address and returns a list of
 // CIDR subnets that fit exactly between the boundaries of the two with no overlap.
 func IPRangeToIPNets(start, end net.IP) ([]*net.IPNet, error) {
+	return IPRangeToIPNetsWithOptions(start, end, Options{})
+}
+
+// IPRangeToIPNetsWithOptions accepts an arbitrary start and end IP address and returns a list of
+// CIDR subnets that fit between the boundaries of the two with no overlap, none shorter than the
+// minimum prefix length. It returns ErrTooManyBlocks if splitting the range at the minimum prefix length
+// would add more than MaxSubnets subnets. A range needing a subnet longer than the maximum prefix length
+// is rejected with a *PrefixTooLongError, or widened to the boundaries of the maximum prefix length if Expand is set.
+func IPRangeToIPNetsWithOptions(start, end net.IP, opts Options) ([]*net.IPNet, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
 	start4 := start.To4()
 	end4 := end.To4()
 
 	if ((start4 == nil) && (end4 != nil)) || ((start4 != nil) && (end4 == nil)) {
-		return nil, errors.New("Mismatched IP address types")
+		return nil, ErrMismatchedFamily
 	}
 
 	var cidrs []*net.IPNet
+	var maxPrefix int
 
 	if start4 != nil {
 		lo := ipv4ToUInt32(start4)
 		hi := ipv4ToUInt32(end4)
 		if hi < lo {
-			return nil, errors.New("End < Start")
+			return nil, ErrRangeReversed
 		}
 
-		if err := splitRange4(0, 0, lo, hi, &cidrs); err != nil {
+		maxPrefix = opts.MaxPrefix4
+		if maxPrefix != 0 && opts.Expand {
+			lo = network4(lo, uint(maxPrefix))
+			hi = broadcast4(hi, uint(maxPrefix))
+		}
+		if minPrefixSplits4(lo, hi, uint(opts.MinPrefix4)) > MaxSubnets {
+			return nil, ErrTooManyBlocks
+		}
+		if err := splitRange4(0, 0, lo, hi, uint(opts.MinPrefix4), appendIPNet4(&cidrs)); err != nil {
 			return nil, err
 		}
 	} else {
 		start6 := start.To16()
 		if start6 == nil {
-			return nil, fmt.Errorf("Invalid IP address: %v", start)
+			return nil, &ParseError{Kind: KindIP, Input: start.String(), Index: -1}
 		}
 		end6 := end.To16()
 		if end6 == nil {
-			return nil, fmt.Errorf("Invalid IP address: %v", end)
+			return nil, &ParseError{Kind: KindIP, Input: end.String(), Index: -1}
 		}
 
 		lo := ipv6ToUInt128(start6)
 		hi := ipv6ToUInt128(end6)
-		if hi.Cmp(lo) < 0 {
-			return nil, errors.New("End < Start")
+		if hi.cmp(lo) < 0 {
+			return nil, ErrRangeReversed
+		}
+
+		maxPrefix = opts.MaxPrefix6
+		if maxPrefix != 0 && opts.Expand {
+			lo = network6(lo, uint(maxPrefix))
+			hi = broadcast6(hi, uint(maxPrefix))
 		}
-		if err := splitRange6(big.NewInt(0), 0, lo, hi, &cidrs); err != nil {
+		if minPrefixSplits6(lo, hi, uint(opts.MinPrefix6)) > MaxSubnets {
+			return nil, ErrTooManyBlocks
+		}
+		if err := splitRange6(uint128{}, 0, lo, hi, uint(opts.MinPrefix6), appendIPNet6(&cidrs)); err != nil {
 			return nil, err
 		}
 	}
 
+	// The subnets at the ends of the range are the longest needed.
+	if maxPrefix != 0 {
+		longest := 0
+		for _, cidr := range cidrs {
+			if ones, _ := cidr.Mask.Size(); ones > longest {
+				longest = ones
+			}
+		}
+		if longest > maxPrefix {
+			return nil, &PrefixTooLongError{Index: -1, Input: start.String() + "-" + end.String(), Prefix: longest, Max: maxPrefix}
+		}
+	}
+
 	return cidrs, nil
 }
 
 // IPRangeToCIDRs accepts an arbitrary start and end IP address and returns a list of
 // CIDR subnets that fit exactly between the boundaries of the two with no overlap.
 func IPRangeToCIDRs(start, end string) ([]string, error) {
+	return IPRangeToCIDRsWithOptions(start, end, Options{})
+}
+
+// IPRangeToCIDRsWithOptions accepts an arbitrary start and end IP address and returns a list of
+// CIDR subnets that fit between the boundaries of the two, limited by the prefix lengths of the options.
+func IPRangeToCIDRsWithOptions(start, end string, opts Options) ([]string, error) {
 	ipStart := net.ParseIP(start)
 	if ipStart == nil {
-		return nil, fmt.Errorf("Invalid IP address: %s", start)
+		return nil, &ParseError{Kind: KindIP, Input: start, Index: -1}
 	}
 	ipEnd := net.ParseIP(end)
 	if ipEnd == nil {
-		return nil, fmt.Errorf("Invalid IP address: %s", end)
+		return nil, &ParseError{Kind: KindIP, Input: end, Index: -1}
 	}
 
-	nets, err := IPRangeToIPNets(ipStart, ipEnd)
+	nets, err := IPRangeToIPNetsWithOptions(ipStart, ipEnd, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	return ipNets(nets).toCIDRs(), nil
 }
+
+// expandRangeEnd expands an abbreviated range end, such as the "50" in "10.0.0.1-50", by replacing
+// the trailing octets (IPv4) or groups (IPv6) of the start address.
+func expandRangeEnd(start net.IP, end string) net.IP {
+	if start4 := start.To4(); start4 != nil {
+		parts := strings.Split(end, ".")
+		if len(parts) >= net.IPv4len {
+			return nil
+		}
+
+		ip := make(net.IP, net.IPv4len)
+		copy(ip, start4)
+		offset := net.IPv4len - len(parts)
+		for i, part := range parts {
+			octet, err := strconv.ParseUint(part, 10, 8)
+			if err != nil {
+				return nil
+			}
+			ip[offset+i] = byte(octet)
+		}
+		return ip
+	}
+
+	parts := strings.Split(end, ":")
+	if len(parts) >= net.IPv6len/2 {
+		return nil
+	}
+
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, start.To16())
+	offset := net.IPv6len - 2*len(parts)
+	for i, part := range parts {
+		group, err := strconv.ParseUint(part, 16, 16)
+		if err != nil {
+			return nil
+		}
+		ip[offset+2*i] = byte(group >> 8)
+		ip[offset+2*i+1] = byte(group)
+	}
+	return ip
+}
+
+// ParseRange parses an IP range in "start-end" notation and returns the start and end IP address.
+// The end may be abbreviated to its trailing octets or groups, such as "10.0.0.1-50",
+// "10.0.0.1-1.50" or "2001:db8::1-ff".
+// Errors are returned as a *ParseError, wrapping ErrMismatchedFamily or ErrRangeReversed where applicable.
+func ParseRange(r string) (net.IP, net.IP, error) {
+	rangeErr := func(err error) error {
+		return &ParseError{Kind: KindRange, Input: r, Index: -1, Err: err}
+	}
+
+	i := strings.IndexByte(r, '-')
+	if i < 0 {
+		return nil, nil, rangeErr(nil)
+	}
+	startStr := strings.TrimSpace(r[:i])
+	endStr := strings.TrimSpace(r[i+1:])
+
+	start := net.ParseIP(startStr)
+	if start == nil {
+		return nil, nil, rangeErr(&ParseError{Kind: KindIP, Input: startStr, Index: -1})
+	}
+	end := net.ParseIP(endStr)
+	if end == nil {
+		end = expandRangeEnd(start, endStr)
+		if end == nil {
+			return nil, nil, rangeErr(&ParseError{Kind: KindIP, Input: endStr, Index: -1})
+		}
+	}
+
+	if start4 := start.To4(); start4 != nil {
+		end4 := end.To4()
+		if end4 == nil {
+			return nil, nil, rangeErr(ErrMismatchedFamily)
+		}
+		start, end = start4, end4
+	} else {
+		if end.To4() != nil {
+			return nil, nil, rangeErr(ErrMismatchedFamily)
+		}
+		end = end.To16()
+	}
+
+	if bytes.Compare(end, start) < 0 {
+		return nil, nil, rangeErr(ErrRangeReversed)
+	}
+
+	return start, end, nil
+}
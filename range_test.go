@@ -3,6 +3,7 @@
 package cidrman
 
 import (
+	"net"
 	"reflect"
 	"testing"
 )
@@ -178,3 +179,78 @@ func TestIPRangeToCIDRs(t *testing.T) {
 		}
 	}
 }
+
+func TestParseRange(t *testing.T) {
+	type TestCase struct {
+		Input string
+		Start string
+		End   string
+		Error bool
+	}
+
+	testCases := []TestCase{
+		{Input: "", Error: true},
+		{Input: "192.0.2.1", Error: true},
+		{Input: "192.0.2.1-", Error: true},
+		{Input: "192.0.2.1-192.0.2.300", Error: true},
+		{Input: "192.0.2.10-192.0.2.1", Error: true},
+		{Input: "192.0.2.10-5", Error: true},
+		{Input: "192.0.2.1-256", Error: true},
+		{Input: "192.0.2.1-1.2.3.4.5", Error: true},
+		{Input: "192.0.2.1-2001:db8::1", Error: true},
+		{Input: "2001:db8::1-192.0.2.1", Error: true},
+		{Input: "2001:db8::1-fffff", Error: true},
+		{Input: "192.0.2.1-192.0.2.50", Start: "192.0.2.1", End: "192.0.2.50"},
+		{Input: "192.0.2.1 - 192.0.2.50", Start: "192.0.2.1", End: "192.0.2.50"},
+		{Input: "192.0.2.1-50", Start: "192.0.2.1", End: "192.0.2.50"},
+		{Input: "192.0.2.1-3.50", Start: "192.0.2.1", End: "192.0.3.50"},
+		{Input: "10.0.0.0-255.255.255", Start: "10.0.0.0", End: "10.255.255.255"},
+		{Input: "192.0.2.7-192.0.2.7", Start: "192.0.2.7", End: "192.0.2.7"},
+		{Input: "2001:db8::1-2001:db8::ff", Start: "2001:db8::1", End: "2001:db8::ff"},
+		{Input: "2001:db8::1-ff", Start: "2001:db8::1", End: "2001:db8::ff"},
+		{Input: "2001:db8::1-1:ff", Start: "2001:db8::1", End: "2001:db8::1:ff"},
+	}
+
+	for _, testCase := range testCases {
+		start, end, err := ParseRange(testCase.Input)
+		if err != nil {
+			if !testCase.Error {
+				t.Errorf("ParseRange(%#v) failed: %s", testCase.Input, err.Error())
+			}
+			continue
+		}
+		if testCase.Error {
+			t.Errorf("ParseRange(%#v) expected error, got: %s, %s", testCase.Input, start, end)
+			continue
+		}
+		if start.String() != testCase.Start || end.String() != testCase.End {
+			t.Errorf("ParseRange(%#v) expected: %s, %s, got: %s, %s", testCase.Input, testCase.Start, testCase.End, start, end)
+		}
+	}
+}
+
+// go test -run=NONE -bench="BenchmarkIPRangeToIPNets"
+
+func BenchmarkIPRangeToIPNets4(b *testing.B) {
+	start := net.ParseIP("0.0.0.1")
+	end := net.ParseIP("255.255.255.254")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := IPRangeToIPNets(start, end); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkIPRangeToIPNets6(b *testing.B) {
+	start := net.ParseIP("::1")
+	end := net.ParseIP("ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := IPRangeToIPNets(start, end); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Inspired by the Python netaddr cidr_exclude function
+// https://netaddr.readthedocs.io/en/latest/api.html#netaddr.cidr_exclude.
+
+package cidrman
+
+import (
+	"net"
+)
+
+// RemoveIPNets accepts a list of base IP networks and a list of IP networks to exclude from them.
+// It returns the smallest possible list of IPNets covering the base networks minus the excluded ones.
+func RemoveIPNets(base, exclude []*net.IPNet) ([]*net.IPNet, error) {
+	if base == nil {
+		return nil, nil
+	}
+	if len(base) == 0 {
+		return make([]*net.IPNet, 0), nil
+	}
+
+	// Split into IPv4 and IPv6 lists.
+	// Remove from the lists separately and then combine.
+	base4s, base6s := ipNets(base).toBlocks()
+	exclude4s, exclude6s := ipNets(exclude).toBlocks()
+
+	remaining4, err := remove4(base4s, exclude4s).toIPNets()
+	if err != nil {
+		return nil, err
+	}
+
+	remaining6, err := remove6(base6s, exclude6s).toIPNets()
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := append(remaining4, remaining6...)
+	if remaining == nil {
+		return make([]*net.IPNet, 0), nil
+	}
+	return remaining, nil
+}
+
+// RemoveCIDRs accepts a list of base CIDR blocks and a list of CIDR blocks to exclude from them.
+// It returns the smallest possible list of CIDRs covering the base blocks minus the excluded ones.
+func RemoveCIDRs(base, exclude []string) ([]string, error) {
+	if base == nil {
+		return nil, nil
+	}
+	if len(base) == 0 {
+		return make([]string, 0), nil
+	}
+
+	baseNets, err := parseCIDRs(base)
+	if err != nil {
+		return nil, err
+	}
+	excludeNets, err := parseCIDRs(exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	remainingNets, err := RemoveIPNets(baseNets, excludeNets)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := ipNets(remainingNets).toCIDRs()
+	if remaining == nil {
+		return make([]string, 0), nil
+	}
+	return remaining, nil
+}
@@ -0,0 +1,173 @@
+// go test -v -run="TestRemoveCIDRs"
+
+package cidrman
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRemoveCIDRs(t *testing.T) {
+	type TestCase struct {
+		Base    []string
+		Exclude []string
+		Output  []string
+		Error   bool
+	}
+
+	testCases := []TestCase{
+		{
+			Base:    nil,
+			Exclude: nil,
+			Output:  nil,
+			Error:   false,
+		},
+		{
+			Base:    []string{},
+			Exclude: []string{"10.0.0.0/8"},
+			Output:  []string{},
+			Error:   false,
+		},
+		{
+			Base:    []string{"10.0.0.0/8"},
+			Exclude: []string{"abcdefgh"},
+			Output:  nil,
+			Error:   true,
+		},
+		{
+			Base:    []string{"10.0.0.0/8"},
+			Exclude: nil,
+			Output: []string{
+				"10.0.0.0/8",
+			},
+			Error: false,
+		},
+		{
+			Base:    []string{"10.0.0.0/8"},
+			Exclude: []string{"0.0.0.0/0"},
+			Output:  []string{},
+			Error:   false,
+		},
+		{
+			Base:    []string{"10.0.0.0/8"},
+			Exclude: []string{"192.0.2.0/24"},
+			Output: []string{
+				"10.0.0.0/8",
+			},
+			Error: false,
+		},
+		{
+			Base:    []string{"192.0.2.0/24"},
+			Exclude: []string{"192.0.2.0/25"},
+			Output: []string{
+				"192.0.2.128/25",
+			},
+			Error: false,
+		},
+		{
+			Base:    []string{"192.0.2.0/24"},
+			Exclude: []string{"192.0.2.64/27"},
+			Output: []string{
+				"192.0.2.0/26",
+				"192.0.2.96/27",
+				"192.0.2.128/25",
+			},
+			Error: false,
+		},
+		{
+			Base: []string{
+				"192.0.2.0/24",
+				"198.51.100.0/24",
+			},
+			Exclude: []string{
+				"192.0.2.255/32",
+				"192.0.2.0/32",
+				"198.51.100.0/25",
+			},
+			Output: []string{
+				"192.0.2.1/32",
+				"192.0.2.2/31",
+				"192.0.2.4/30",
+				"192.0.2.8/29",
+				"192.0.2.16/28",
+				"192.0.2.32/27",
+				"192.0.2.64/26",
+				"192.0.2.128/26",
+				"192.0.2.192/27",
+				"192.0.2.224/28",
+				"192.0.2.240/29",
+				"192.0.2.248/30",
+				"192.0.2.252/31",
+				"192.0.2.254/32",
+				"198.51.100.128/25",
+			},
+			Error: false,
+		},
+		// A single exclusion spanning several base blocks.
+		{
+			Base: []string{
+				"192.0.2.0/24",
+				"192.0.4.0/24",
+			},
+			Exclude: []string{
+				"192.0.0.0/16",
+			},
+			Output: []string{},
+			Error:  false,
+		},
+		{
+			Base:    []string{"0.0.0.0/0"},
+			Exclude: []string{"128.0.0.0/1", "255.255.255.255/32"},
+			Output: []string{
+				"0.0.0.0/1",
+			},
+			Error: false,
+		},
+		{
+			Base:    []string{"2001:db8::/32"},
+			Exclude: []string{"2001:db8::/33"},
+			Output: []string{
+				"2001:db8:8000::/33",
+			},
+			Error: false,
+		},
+		{
+			Base:    []string{"fd00::/126"},
+			Exclude: []string{"fd00::1/128", "fd00::2/128"},
+			Output: []string{
+				"fd00::/128",
+				"fd00::3/128",
+			},
+			Error: false,
+		},
+		// Mixed IPv4 and IPv6 tests
+		{
+			Base: []string{
+				"192.0.2.0/24",
+				"2001:db8::/32",
+			},
+			Exclude: []string{
+				"2001:db8::/33",
+				"192.0.2.128/25",
+			},
+			Output: []string{
+				"192.0.2.0/25",
+				"2001:db8:8000::/33",
+			},
+			Error: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		output, err := RemoveCIDRs(testCase.Base, testCase.Exclude)
+		if err != nil {
+			if !testCase.Error {
+				t.Errorf("RemoveCIDRs(%#v, %#v) failed: %s", testCase.Base, testCase.Exclude, err.Error())
+			}
+			continue
+		}
+		if !reflect.DeepEqual(testCase.Output, output) {
+			t.Errorf("RemoveCIDRs(%#v, %#v) expected: %#v, got: %#v", testCase.Base, testCase.Exclude, testCase.Output, output)
+		}
+	}
+}
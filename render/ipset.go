@@ -0,0 +1,63 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Netnod/go-cidrman"
+)
+
+// defaultMaxElem is the default maximum number of entries of an ipset set.
+const defaultMaxElem = 65536
+
+// IPSetRestore writes the CIDR blocks as a script for ipset restore. The IPv4 blocks go in the
+// hash:net set name_v4 of family inet and the IPv6 blocks in the set name_v6 of family inet6.
+// Each set is created if missing, with a maxelem large enough for the blocks, and flushed before
+// the blocks are added, so the script can be restored repeatedly. A set is only written if it has
+// entries. As hash:net does not accept a zero prefix length, 0.0.0.0/0 and ::/0 are written as two /1s.
+func IPSetRestore(w io.Writer, name string, cidrs []string) error {
+	cidrs4, cidrs6, err := split(cidrs)
+	if err != nil {
+		return err
+	}
+	if cidrs4, err = splitDefault(cidrs4); err != nil {
+		return err
+	}
+	if cidrs6, err = splitDefault(cidrs6); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	ipset(&b, name+suffix4, "inet", cidrs4)
+	ipset(&b, name+suffix6, "inet6", cidrs6)
+
+	return write(w, &b)
+}
+
+// ipset writes the commands to create, flush and fill a set of the specified family, unless there are no entries.
+func ipset(b *strings.Builder, name, family string, cidrs []string) {
+	if len(cidrs) == 0 {
+		return
+	}
+
+	maxElem := defaultMaxElem
+	if len(cidrs) > maxElem {
+		maxElem = len(cidrs)
+	}
+
+	fmt.Fprintf(b, "create %s hash:net family %s maxelem %d -exist\n", name, family, maxElem)
+	fmt.Fprintf(b, "flush %s\n", name)
+	for _, cidr := range cidrs {
+		fmt.Fprintf(b, "add %s %s\n", name, cidr)
+	}
+}
+
+// splitDefault replaces a merged default route, the only block of its family, with its two /1 halves.
+func splitDefault(cidrs []string) ([]string, error) {
+	if len(cidrs) != 1 || !strings.HasSuffix(cidrs[0], "/0") {
+		return cidrs, nil
+	}
+
+	return cidrman.Subnets(cidrs[0], 1)
+}
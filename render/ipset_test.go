@@ -0,0 +1,94 @@
+// go test -v -run="TestIPSetRestore"
+
+package render
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestIPSetRestore(t *testing.T) {
+	type TestCase struct {
+		Name   string
+		Input  []string
+		Output string
+		Error  bool
+	}
+
+	testCases := []TestCase{
+		{
+			Name:   "blocked",
+			Input:  []string{},
+			Output: "",
+		},
+		{
+			Name:  "blocked",
+			Input: []string{"not-a-cidr"},
+			Error: true,
+		},
+		{
+			Name:  "blocked",
+			Input: []string{"2001:db8:8000::/33", "198.51.100.7/32", "2001:db8::/33"},
+			Output: "create blocked_v4 hash:net family inet maxelem 65536 -exist\n" +
+				"flush blocked_v4\n" +
+				"add blocked_v4 198.51.100.7/32\n" +
+				"create blocked_v6 hash:net family inet6 maxelem 65536 -exist\n" +
+				"flush blocked_v6\n" +
+				"add blocked_v6 2001:db8::/32\n",
+		},
+		{
+			Name:  "blocked",
+			Input: []string{"2001:db8::/32"},
+			Output: "create blocked_v6 hash:net family inet6 maxelem 65536 -exist\n" +
+				"flush blocked_v6\n" +
+				"add blocked_v6 2001:db8::/32\n",
+		},
+		{
+			Name:  "any",
+			Input: []string{"10.0.0.0/8", "0.0.0.0/0", "::/0"},
+			Output: "create any_v4 hash:net family inet maxelem 65536 -exist\n" +
+				"flush any_v4\n" +
+				"add any_v4 0.0.0.0/1\n" +
+				"add any_v4 128.0.0.0/1\n" +
+				"create any_v6 hash:net family inet6 maxelem 65536 -exist\n" +
+				"flush any_v6\n" +
+				"add any_v6 ::/1\n" +
+				"add any_v6 8000::/1\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		var b strings.Builder
+		err := IPSetRestore(&b, testCase.Name, testCase.Input)
+		if err != nil {
+			if !testCase.Error {
+				t.Errorf("IPSetRestore(%#v, %#v) failed: %s", testCase.Name, testCase.Input, err.Error())
+			}
+			continue
+		}
+		if testCase.Error {
+			t.Errorf("IPSetRestore(%#v, %#v) expected error, got: %q", testCase.Name, testCase.Input, b.String())
+			continue
+		}
+		if b.String() != testCase.Output {
+			t.Errorf("IPSetRestore(%#v, %#v) expected: %q, got: %q", testCase.Name, testCase.Input, testCase.Output, b.String())
+		}
+	}
+}
+
+func TestIPSetRestoreMaxElem(t *testing.T) {
+	// Every other IPv4 address, so the blocks do not merge.
+	cidrs := make([]string, 70000)
+	for i := range cidrs {
+		cidrs[i] = fmt.Sprintf("10.%d.%d.%d/32", i>>15, (i>>7)&0xff, (i<<1)&0xff)
+	}
+
+	var b strings.Builder
+	if err := IPSetRestore(&b, "large", cidrs); err != nil {
+		t.Fatalf("IPSetRestore failed: %s", err.Error())
+	}
+	if expected := "create large_v4 hash:net family inet maxelem 70000 -exist\n"; !strings.HasPrefix(b.String(), expected) {
+		t.Errorf("IPSetRestore expected to start with: %q, got: %q", expected, b.String()[:len(expected)])
+	}
+}
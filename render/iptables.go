@@ -0,0 +1,60 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Netnod/go-cidrman"
+)
+
+// Rule configures the rules written by IPTablesRestore.
+type Rule struct {
+	// Table is the table of the chain, "filter" if empty.
+	Table string
+	// Chain is the user-defined chain the rules are appended to. It is declared, and so flushed, by the restore.
+	Chain string
+	// Target is the target jumped to for matching packets, such as ACCEPT or DROP.
+	Target string
+	// Destination matches the destination address instead of the source address.
+	Destination bool
+}
+
+// IPTablesRestore writes the CIDR blocks of the address family as a table block for iptables-restore,
+// or ip6tables-restore for IPv6, with one rule per block. Blocks of the other family are left out.
+func IPTablesRestore(w io.Writer, family cidrman.Family, rule Rule, cidrs []string) error {
+	if family != cidrman.IPv4 && family != cidrman.IPv6 {
+		return fmt.Errorf("%w: %v", cidrman.ErrInvalidFamily, family)
+	}
+	if rule.Chain == "" || rule.Target == "" {
+		return fmt.Errorf("Missing chain or target")
+	}
+
+	cidrs4, cidrs6, err := split(cidrs)
+	if err != nil {
+		return err
+	}
+	selected := cidrs4
+	if family == cidrman.IPv6 {
+		selected = cidrs6
+	}
+
+	table := rule.Table
+	if table == "" {
+		table = "filter"
+	}
+	match := "-s"
+	if rule.Destination {
+		match = "-d"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s\n", table)
+	fmt.Fprintf(&b, ":%s - [0:0]\n", rule.Chain)
+	for _, cidr := range selected {
+		fmt.Fprintf(&b, "-A %s %s %s -j %s\n", rule.Chain, match, cidr, rule.Target)
+	}
+	fmt.Fprintf(&b, "COMMIT\n")
+
+	return write(w, &b)
+}
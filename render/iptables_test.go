@@ -0,0 +1,93 @@
+// go test -v -run="TestIPTablesRestore"
+
+package render
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Netnod/go-cidrman"
+)
+
+func TestIPTablesRestore(t *testing.T) {
+	type TestCase struct {
+		Family cidrman.Family
+		Rule   Rule
+		Input  []string
+		Output string
+		Error  bool
+	}
+
+	cidrs := []string{"192.0.2.0/25", "192.0.2.128/25", "10.0.0.0/8", "2001:db8::/32"}
+
+	testCases := []TestCase{
+		{
+			Family: cidrman.Family(5),
+			Rule:   Rule{Chain: "ALLOW", Target: "ACCEPT"},
+			Input:  cidrs,
+			Error:  true,
+		},
+		{
+			Family: cidrman.IPv4,
+			Rule:   Rule{Chain: "ALLOW"},
+			Input:  cidrs,
+			Error:  true,
+		},
+		{
+			Family: cidrman.IPv4,
+			Rule:   Rule{Chain: "ALLOW", Target: "ACCEPT"},
+			Input:  []string{"10.0.0.0/33"},
+			Error:  true,
+		},
+		{
+			Family: cidrman.IPv4,
+			Rule:   Rule{Chain: "ALLOW", Target: "ACCEPT"},
+			Input:  cidrs,
+			Output: "*filter\n" +
+				":ALLOW - [0:0]\n" +
+				"-A ALLOW -s 10.0.0.0/8 -j ACCEPT\n" +
+				"-A ALLOW -s 192.0.2.0/24 -j ACCEPT\n" +
+				"COMMIT\n",
+		},
+		{
+			Family: cidrman.IPv6,
+			Rule:   Rule{Table: "raw", Chain: "BLOCK", Target: "DROP", Destination: true},
+			Input:  cidrs,
+			Output: "*raw\n" +
+				":BLOCK - [0:0]\n" +
+				"-A BLOCK -d 2001:db8::/32 -j DROP\n" +
+				"COMMIT\n",
+		},
+		{
+			Family: cidrman.IPv6,
+			Rule:   Rule{Chain: "ALLOW", Target: "ACCEPT"},
+			Input:  []string{"10.0.0.0/8"},
+			Output: "*filter\n" +
+				":ALLOW - [0:0]\n" +
+				"COMMIT\n",
+		},
+	}
+
+	if err := IPTablesRestore(&strings.Builder{}, cidrman.Family(5), Rule{Chain: "ALLOW", Target: "ACCEPT"}, cidrs); !errors.Is(err, cidrman.ErrInvalidFamily) {
+		t.Errorf("IPTablesRestore(Family(5)) expected error: %v, got: %v", cidrman.ErrInvalidFamily, err)
+	}
+
+	for _, testCase := range testCases {
+		var b strings.Builder
+		err := IPTablesRestore(&b, testCase.Family, testCase.Rule, testCase.Input)
+		if err != nil {
+			if !testCase.Error {
+				t.Errorf("IPTablesRestore(%v, %#v, %#v) failed: %s", testCase.Family, testCase.Rule, testCase.Input, err.Error())
+			}
+			continue
+		}
+		if testCase.Error {
+			t.Errorf("IPTablesRestore(%v, %#v, %#v) expected error, got: %q", testCase.Family, testCase.Rule, testCase.Input, b.String())
+			continue
+		}
+		if b.String() != testCase.Output {
+			t.Errorf("IPTablesRestore(%v, %#v, %#v) expected: %q, got: %q", testCase.Family, testCase.Rule, testCase.Input, testCase.Output, b.String())
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// NftablesSets writes the CIDR blocks as nftables named set definitions with interval flags,
+// for inclusion in a table. The IPv4 blocks go in the set name_v4 of type ipv4_addr and the
+// IPv6 blocks in the set name_v6 of type ipv6_addr. A set is only written if it has elements.
+func NftablesSets(w io.Writer, name string, cidrs []string) error {
+	cidrs4, cidrs6, err := split(cidrs)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	nftablesSet(&b, name+suffix4, "ipv4_addr", cidrs4)
+	nftablesSet(&b, name+suffix6, "ipv6_addr", cidrs6)
+
+	return write(w, &b)
+}
+
+// nftablesSet writes a set definition of the specified type, unless there are no elements.
+func nftablesSet(b *strings.Builder, name, typ string, cidrs []string) {
+	if len(cidrs) == 0 {
+		return
+	}
+
+	fmt.Fprintf(b, "set %s {\n", name)
+	fmt.Fprintf(b, "\ttype %s\n", typ)
+	fmt.Fprintf(b, "\tflags interval\n")
+	fmt.Fprintf(b, "\telements = {\n")
+	for i, cidr := range cidrs {
+		if i < len(cidrs)-1 {
+			fmt.Fprintf(b, "\t\t%s,\n", cidr)
+		} else {
+			fmt.Fprintf(b, "\t\t%s\n", cidr)
+		}
+	}
+	fmt.Fprintf(b, "\t}\n")
+	fmt.Fprintf(b, "}\n")
+}
@@ -0,0 +1,78 @@
+// go test -v -run="TestNftablesSets"
+
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNftablesSets(t *testing.T) {
+	type TestCase struct {
+		Name   string
+		Input  []string
+		Output string
+		Error  bool
+	}
+
+	testCases := []TestCase{
+		{
+			Name:   "allow",
+			Input:  nil,
+			Output: "",
+		},
+		{
+			Name:  "allow",
+			Input: []string{"192.0.2.300/24"},
+			Error: true,
+		},
+		{
+			Name:  "allow",
+			Input: []string{"192.0.2.0/25", "192.0.2.128/25", "10.0.0.0/8"},
+			Output: "set allow_v4 {\n" +
+				"\ttype ipv4_addr\n" +
+				"\tflags interval\n" +
+				"\telements = {\n" +
+				"\t\t10.0.0.0/8,\n" +
+				"\t\t192.0.2.0/24\n" +
+				"\t}\n" +
+				"}\n",
+		},
+		{
+			Name:  "allow",
+			Input: []string{"2001:db8::/32", "10.0.0.0/8"},
+			Output: "set allow_v4 {\n" +
+				"\ttype ipv4_addr\n" +
+				"\tflags interval\n" +
+				"\telements = {\n" +
+				"\t\t10.0.0.0/8\n" +
+				"\t}\n" +
+				"}\n" +
+				"set allow_v6 {\n" +
+				"\ttype ipv6_addr\n" +
+				"\tflags interval\n" +
+				"\telements = {\n" +
+				"\t\t2001:db8::/32\n" +
+				"\t}\n" +
+				"}\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		var b strings.Builder
+		err := NftablesSets(&b, testCase.Name, testCase.Input)
+		if err != nil {
+			if !testCase.Error {
+				t.Errorf("NftablesSets(%#v, %#v) failed: %s", testCase.Name, testCase.Input, err.Error())
+			}
+			continue
+		}
+		if testCase.Error {
+			t.Errorf("NftablesSets(%#v, %#v) expected error, got: %q", testCase.Name, testCase.Input, b.String())
+			continue
+		}
+		if b.String() != testCase.Output {
+			t.Errorf("NftablesSets(%#v, %#v) expected: %q, got: %q", testCase.Name, testCase.Input, testCase.Output, b.String())
+		}
+	}
+}
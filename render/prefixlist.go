@@ -0,0 +1,215 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/Netnod/go-cidrman"
+)
+
+// Widths of IPv4 and IPv6 addresses in bits.
+const (
+	width4 = 32
+	width6 = 128
+)
+
+// maxEnumerated is the most prefixes written for the more-specifics of the CIDR blocks
+// when they are not collapsed into ranges.
+const maxEnumerated = 1 << 16
+
+// PrefixListOptions configures the router prefix lists.
+type PrefixListOptions struct {
+	// MaxLength4 and MaxLength6 are the longest prefix lengths of the more-specifics of each IPv4 and IPv6
+	// block also matched, such as 24 to match 10.0.0.0/8 and any more-specific down to a /24.
+	// Zero, or a length not longer than the block, matches the block exactly.
+	MaxLength4 int
+	MaxLength6 int
+
+	// Collapse writes a block and its more-specifics as one entry with a prefix length range,
+	// le in Cisco, upto or orlonger in Junos and {min,max} or + in BIRD, instead of enumerating them.
+	Collapse bool
+}
+
+// prefixRange is a prefix matching the prefix lengths ge to le.
+type prefixRange struct {
+	prefix string
+	ge     int
+	le     int
+}
+
+// exact reports whether the prefix range only matches the prefix itself.
+func (r prefixRange) exact() bool {
+	return r.ge == r.le
+}
+
+// prefixRanges merges the CIDR blocks and returns the IPv4 and IPv6 prefix ranges to match.
+func prefixRanges(cidrs []string, opts PrefixListOptions) ([]prefixRange, []prefixRange, error) {
+	if opts.MaxLength4 < 0 || opts.MaxLength4 > width4 {
+		return nil, nil, &cidrman.PrefixLengthError{Prefix: opts.MaxLength4, Min: 0, Max: width4}
+	}
+	if opts.MaxLength6 < 0 || opts.MaxLength6 > width6 {
+		return nil, nil, &cidrman.PrefixLengthError{Prefix: opts.MaxLength6, Min: 0, Max: width6}
+	}
+
+	cidrs4, cidrs6, err := split(cidrs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	count := 0
+	ranges4, err := expandRanges(cidrs4, opts.MaxLength4, opts.Collapse, &count)
+	if err != nil {
+		return nil, nil, err
+	}
+	ranges6, err := expandRanges(cidrs6, opts.MaxLength6, opts.Collapse, &count)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ranges4, ranges6, nil
+}
+
+// expandRanges returns the prefix ranges matching the CIDR blocks and their more-specifics up to maxLength,
+// either collapsed or enumerated as exact prefixes. The count of enumerated prefixes is limited to maxEnumerated.
+func expandRanges(cidrs []string, maxLength int, collapse bool, count *int) ([]prefixRange, error) {
+	var ranges []prefixRange
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, &cidrman.ParseError{Kind: cidrman.KindCIDR, Input: cidr, Index: -1}
+		}
+		ones, _ := network.Mask.Size()
+
+		le := ones
+		if maxLength > ones {
+			le = maxLength
+		}
+		if collapse || le == ones {
+			ranges = append(ranges, prefixRange{prefix: cidr, ge: ones, le: le})
+			continue
+		}
+
+		// The block and its more-specifics down to le are 2^(le-ones+1)-1 prefixes.
+		if le-ones >= 16 || *count+(1<<(le-ones+1))-1 > maxEnumerated {
+			return nil, fmt.Errorf("Too many more-specifics of %s up to /%d to enumerate, at most %d", cidr, le, maxEnumerated)
+		}
+		for length := ones; length <= le; length++ {
+			subnets, err := cidrman.Subnets(cidr, length)
+			if err != nil {
+				return nil, err
+			}
+			for _, subnet := range subnets {
+				ranges = append(ranges, prefixRange{prefix: subnet, ge: length, le: length})
+			}
+		}
+		*count += (1 << (le - ones + 1)) - 1
+	}
+
+	return ranges, nil
+}
+
+// CiscoPrefixList writes the CIDR blocks as Cisco IOS prefix lists permitting them, an ip prefix-list
+// for the IPv4 blocks and an ipv6 prefix-list for the IPv6 blocks, both with the specified name and
+// sequence numbers in steps of 5. A list is only written if it has entries.
+func CiscoPrefixList(w io.Writer, name string, cidrs []string, opts PrefixListOptions) error {
+	ranges4, ranges6, err := prefixRanges(cidrs, opts)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	ciscoPrefixList(&b, "ip", name, ranges4)
+	ciscoPrefixList(&b, "ipv6", name, ranges6)
+
+	return write(w, &b)
+}
+
+// ciscoPrefixList writes the entries of a prefix list of the specified kind.
+func ciscoPrefixList(b *strings.Builder, kind, name string, ranges []prefixRange) {
+	for i, r := range ranges {
+		fmt.Fprintf(b, "%s prefix-list %s seq %d permit %s", kind, name, (i+1)*5, r.prefix)
+		if !r.exact() {
+			fmt.Fprintf(b, " le %d", r.le)
+		}
+		fmt.Fprintf(b, "\n")
+	}
+}
+
+// JuniperRouteFilter writes the CIDR blocks as Junos set commands for a policy statement with the
+// specified name accepting them, with route-filter entries in the term v4 for the IPv4 blocks and
+// in the term v6 for the IPv6 blocks. A term is only written if it has entries.
+func JuniperRouteFilter(w io.Writer, name string, cidrs []string, opts PrefixListOptions) error {
+	ranges4, ranges6, err := prefixRanges(cidrs, opts)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	juniperTerm(&b, name, "v4", width4, ranges4)
+	juniperTerm(&b, name, "v6", width6, ranges6)
+
+	return write(w, &b)
+}
+
+// juniperTerm writes the route filters of a policy statement term, matching more-specifics
+// up to the full width of the address family as orlonger.
+func juniperTerm(b *strings.Builder, name, term string, width int, ranges []prefixRange) {
+	if len(ranges) == 0 {
+		return
+	}
+
+	prefix := fmt.Sprintf("set policy-options policy-statement %s term %s", name, term)
+	for _, r := range ranges {
+		switch {
+		case r.exact():
+			fmt.Fprintf(b, "%s from route-filter %s exact\n", prefix, r.prefix)
+		case r.le == width:
+			fmt.Fprintf(b, "%s from route-filter %s orlonger\n", prefix, r.prefix)
+		default:
+			fmt.Fprintf(b, "%s from route-filter %s upto /%d\n", prefix, r.prefix, r.le)
+		}
+	}
+	fmt.Fprintf(b, "%s then accept\n", prefix)
+}
+
+// BIRDPrefixSet writes the CIDR blocks as BIRD prefix set constants, name_v4 for the IPv4 blocks
+// and name_v6 for the IPv6 blocks. A set is only written if it has entries.
+func BIRDPrefixSet(w io.Writer, name string, cidrs []string, opts PrefixListOptions) error {
+	ranges4, ranges6, err := prefixRanges(cidrs, opts)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	birdPrefixSet(&b, name+suffix4, width4, ranges4)
+	birdPrefixSet(&b, name+suffix6, width6, ranges6)
+
+	return write(w, &b)
+}
+
+// birdPrefixSet writes a prefix set constant, matching more-specifics up to the full
+// width of the address family with the + shorthand.
+func birdPrefixSet(b *strings.Builder, name string, width int, ranges []prefixRange) {
+	if len(ranges) == 0 {
+		return
+	}
+
+	fmt.Fprintf(b, "define %s = [\n", name)
+	for i, r := range ranges {
+		fmt.Fprintf(b, "\t%s", r.prefix)
+		switch {
+		case r.exact():
+		case r.le == width:
+			fmt.Fprintf(b, "+")
+		default:
+			fmt.Fprintf(b, "{%d,%d}", r.ge, r.le)
+		}
+		if i < len(ranges)-1 {
+			fmt.Fprintf(b, ",")
+		}
+		fmt.Fprintf(b, "\n")
+	}
+	fmt.Fprintf(b, "];\n")
+}
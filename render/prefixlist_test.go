@@ -0,0 +1,130 @@
+// go test -v -run="TestPrefixList"
+
+package render
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPrefixList(t *testing.T) {
+	type TestCase struct {
+		Writer  string
+		Input   []string
+		Options PrefixListOptions
+		Output  string
+		Error   bool
+	}
+
+	writers := map[string]func(io.Writer, string, []string, PrefixListOptions) error{
+		"cisco":   CiscoPrefixList,
+		"juniper": JuniperRouteFilter,
+		"bird":    BIRDPrefixSet,
+	}
+
+	cidrs := []string{"192.0.2.0/25", "192.0.2.128/25", "10.0.0.0/8", "2001:db8::/32"}
+
+	testCases := []TestCase{
+		{
+			Writer:  "cisco",
+			Input:   []string{"192.0.2.300/24"},
+			Options: PrefixListOptions{},
+			Error:   true,
+		},
+		{
+			Writer:  "cisco",
+			Input:   cidrs,
+			Options: PrefixListOptions{MaxLength4: 33},
+			Error:   true,
+		},
+		{
+			Writer:  "cisco",
+			Input:   cidrs,
+			Options: PrefixListOptions{},
+			Output: "ip prefix-list CUSTOMER seq 5 permit 10.0.0.0/8\n" +
+				"ip prefix-list CUSTOMER seq 10 permit 192.0.2.0/24\n" +
+				"ipv6 prefix-list CUSTOMER seq 5 permit 2001:db8::/32\n",
+		},
+		{
+			Writer:  "cisco",
+			Input:   []string{"192.0.2.0/24", "2001:db8::/32"},
+			Options: PrefixListOptions{MaxLength4: 25},
+			Output: "ip prefix-list CUSTOMER seq 5 permit 192.0.2.0/24\n" +
+				"ip prefix-list CUSTOMER seq 10 permit 192.0.2.0/25\n" +
+				"ip prefix-list CUSTOMER seq 15 permit 192.0.2.128/25\n" +
+				"ipv6 prefix-list CUSTOMER seq 5 permit 2001:db8::/32\n",
+		},
+		{
+			Writer:  "cisco",
+			Input:   cidrs,
+			Options: PrefixListOptions{MaxLength4: 24, MaxLength6: 48, Collapse: true},
+			Output: "ip prefix-list CUSTOMER seq 5 permit 10.0.0.0/8 le 24\n" +
+				"ip prefix-list CUSTOMER seq 10 permit 192.0.2.0/24\n" +
+				"ipv6 prefix-list CUSTOMER seq 5 permit 2001:db8::/32 le 48\n",
+		},
+		{
+			Writer:  "cisco",
+			Input:   []string{"10.0.0.0/8"},
+			Options: PrefixListOptions{MaxLength4: 24},
+			Error:   true,
+		},
+		{
+			Writer:  "juniper",
+			Input:   cidrs,
+			Options: PrefixListOptions{MaxLength4: 24, MaxLength6: 128, Collapse: true},
+			Output: "set policy-options policy-statement CUSTOMER term v4 from route-filter 10.0.0.0/8 upto /24\n" +
+				"set policy-options policy-statement CUSTOMER term v4 from route-filter 192.0.2.0/24 exact\n" +
+				"set policy-options policy-statement CUSTOMER term v4 then accept\n" +
+				"set policy-options policy-statement CUSTOMER term v6 from route-filter 2001:db8::/32 orlonger\n" +
+				"set policy-options policy-statement CUSTOMER term v6 then accept\n",
+		},
+		{
+			Writer:  "juniper",
+			Input:   []string{"2001:db8::/32"},
+			Options: PrefixListOptions{},
+			Output: "set policy-options policy-statement CUSTOMER term v6 from route-filter 2001:db8::/32 exact\n" +
+				"set policy-options policy-statement CUSTOMER term v6 then accept\n",
+		},
+		{
+			Writer:  "bird",
+			Input:   cidrs,
+			Options: PrefixListOptions{MaxLength4: 32, MaxLength6: 48, Collapse: true},
+			Output: "define CUSTOMER_v4 = [\n" +
+				"\t10.0.0.0/8+,\n" +
+				"\t192.0.2.0/24+\n" +
+				"];\n" +
+				"define CUSTOMER_v6 = [\n" +
+				"\t2001:db8::/32{32,48}\n" +
+				"];\n",
+		},
+		{
+			Writer:  "bird",
+			Input:   []string{"192.0.2.0/24"},
+			Options: PrefixListOptions{MaxLength4: 25},
+			Output: "define CUSTOMER_v4 = [\n" +
+				"\t192.0.2.0/24,\n" +
+				"\t192.0.2.0/25,\n" +
+				"\t192.0.2.128/25\n" +
+				"];\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		var b strings.Builder
+		err := writers[testCase.Writer](&b, "CUSTOMER", testCase.Input, testCase.Options)
+		if err != nil {
+			if !testCase.Error {
+				t.Errorf("%s(%#v, %#v) failed: %s", testCase.Writer, testCase.Input, testCase.Options, err.Error())
+			}
+			continue
+		}
+		if testCase.Error {
+			t.Errorf("%s(%#v, %#v) expected error, got: %q", testCase.Writer, testCase.Input, testCase.Options, b.String())
+			continue
+		}
+		if b.String() != testCase.Output {
+			t.Errorf("%s(%#v, %#v) expected: %q, got: %q", testCase.Writer, testCase.Input, testCase.Options, testCase.Output, b.String())
+		}
+	}
+}
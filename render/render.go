@@ -0,0 +1,44 @@
+// Package render writes lists of CIDR blocks as firewall and router configuration:
+// nftables sets, ipset restore scripts, iptables-restore rules and Cisco, Junos
+// and BIRD prefix lists.
+//
+// The CIDR blocks are merged first, so overlapping or adjacent blocks are
+// accepted and the output is the smallest possible list for each family.
+// IPv4 and IPv6 blocks are written to separate sets, rule blocks or lists,
+// as the firewalls and routers do not mix address families.
+package render
+
+import (
+	"io"
+	"strings"
+
+	"github.com/Netnod/go-cidrman"
+)
+
+// Suffixes appended to set names to give one set per address family.
+const (
+	suffix4 = "_v4"
+	suffix6 = "_v6"
+)
+
+// split merges the CIDR blocks and returns the IPv4 and IPv6 blocks separately.
+func split(cidrs []string) ([]string, []string, error) {
+	merged, err := cidrman.MergeCIDRs(cidrs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// MergeCIDRs returns the IPv4 blocks before the IPv6 blocks.
+	i := 0
+	for i < len(merged) && !strings.Contains(merged[i], ":") {
+		i++
+	}
+
+	return merged[:i], merged[i:], nil
+}
+
+// write writes the rendered output in one go.
+func write(w io.Writer, b *strings.Builder) error {
+	_, err := io.WriteString(w, b.String())
+	return err
+}
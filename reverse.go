@@ -0,0 +1,99 @@
+package cidrman
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// reverseZone4 returns the in-addr.arpa zone name of an IPv4 network.
+// Networks of a whole number of octets map to the plain zone, networks longer than /24
+// to the RFC 2317 classless delegation name, such as 0/26.2.0.192.in-addr.arpa.
+func reverseZone4(network *net.IPNet) string {
+	ip := network.IP.To4()
+	prefix, _ := network.Mask.Size()
+
+	var labels []string
+	switch {
+	case prefix == widthUInt32:
+		labels = append(labels, fmt.Sprintf("%d", ip[3]))
+	case prefix > 24:
+		labels = append(labels, fmt.Sprintf("%d/%d", ip[3], prefix))
+	}
+	for i := 2; i >= 0; i-- {
+		if prefix > 8*i {
+			labels = append(labels, fmt.Sprintf("%d", ip[i]))
+		}
+	}
+	labels = append(labels, "in-addr", "arpa")
+
+	return strings.Join(labels, ".")
+}
+
+// reverseZone6 returns the ip6.arpa zone name of a nibble-aligned IPv6 network.
+func reverseZone6(network *net.IPNet) string {
+	ip := network.IP.To16()
+	prefix, _ := network.Mask.Size()
+
+	var labels []string
+	for i := prefix/4 - 1; i >= 0; i-- {
+		nibble := ip[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		labels = append(labels, fmt.Sprintf("%x", nibble&0xf))
+	}
+	labels = append(labels, "ip6", "arpa")
+
+	return strings.Join(labels, ".")
+}
+
+// ReverseZonesIPNets returns the minimal list of reverse DNS zone names covering the IP networks.
+// The networks are merged first. IPv4 networks are split on octet boundaries into in-addr.arpa zones,
+// using RFC 2317 classless delegation names for networks longer than /24. IPv6 networks are
+// split on nibble boundaries into ip6.arpa zones.
+func ReverseZonesIPNets(nets []*net.IPNet) ([]string, error) {
+	merged, err := MergeIPNets(nets)
+	if err != nil {
+		return nil, err
+	}
+
+	zones := make([]string, 0, len(merged))
+	for _, network := range merged {
+		prefix, _ := network.Mask.Size()
+		if network.IP.To4() != nil {
+			if prefix >= 24 || prefix%8 == 0 {
+				zones = append(zones, reverseZone4(network))
+				continue
+			}
+
+			subnets, err := SubnetsIPNet(network, (prefix/8+1)*8)
+			if err != nil {
+				return nil, err
+			}
+			for _, subnet := range subnets {
+				zones = append(zones, reverseZone4(subnet))
+			}
+		} else {
+			subnets, err := SubnetsIPNet(network, (prefix+3)/4*4)
+			if err != nil {
+				return nil, err
+			}
+			for _, subnet := range subnets {
+				zones = append(zones, reverseZone6(subnet))
+			}
+		}
+	}
+
+	return zones, nil
+}
+
+// ReverseZones returns the minimal list of reverse DNS zone names covering the CIDR blocks.
+func ReverseZones(cidrs []string) ([]string, error) {
+	networks, err := parseCIDRs(cidrs)
+	if err != nil {
+		return nil, err
+	}
+
+	return ReverseZonesIPNets(networks)
+}
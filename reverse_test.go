@@ -0,0 +1,120 @@
+// go test -v -run="TestReverseZones"
+
+package cidrman
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReverseZones(t *testing.T) {
+	type TestCase struct {
+		Input  []string
+		Output []string
+		Error  bool
+	}
+
+	testCases := []TestCase{
+		{
+			Input:  []string{"abcdefgh"},
+			Output: nil,
+			Error:  true,
+		},
+		{
+			Input:  []string{},
+			Output: []string{},
+			Error:  false,
+		},
+		{
+			Input: []string{"0.0.0.0/0"},
+			Output: []string{
+				"in-addr.arpa",
+			},
+			Error: false,
+		},
+		{
+			Input: []string{"10.0.0.0/8", "172.16.0.0/16", "192.0.2.0/24"},
+			Output: []string{
+				"10.in-addr.arpa",
+				"16.172.in-addr.arpa",
+				"2.0.192.in-addr.arpa",
+			},
+			Error: false,
+		},
+		{
+			Input: []string{"192.168.16.0/22"},
+			Output: []string{
+				"16.168.192.in-addr.arpa",
+				"17.168.192.in-addr.arpa",
+				"18.168.192.in-addr.arpa",
+				"19.168.192.in-addr.arpa",
+			},
+			Error: false,
+		},
+		{
+			Input: []string{"10.0.0.0/7"},
+			Output: []string{
+				"10.in-addr.arpa",
+				"11.in-addr.arpa",
+			},
+			Error: false,
+		},
+		{
+			// Adjacent and contained blocks are merged before the zones are computed.
+			Input: []string{"192.0.2.0/26", "192.0.2.64/26", "192.0.2.192/27", "192.0.2.200/32"},
+			Output: []string{
+				"0/25.2.0.192.in-addr.arpa",
+				"192/27.2.0.192.in-addr.arpa",
+			},
+			Error: false,
+		},
+		{
+			Input: []string{"192.0.2.1/32"},
+			Output: []string{
+				"1.2.0.192.in-addr.arpa",
+			},
+			Error: false,
+		},
+		{
+			Input: []string{"2001:db8::/32"},
+			Output: []string{
+				"8.b.d.0.1.0.0.2.ip6.arpa",
+			},
+			Error: false,
+		},
+		{
+			Input: []string{"2001:db8:8::/45"},
+			Output: []string{
+				"8.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+				"9.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+				"a.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+				"b.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+				"c.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+				"d.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+				"e.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+				"f.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+			},
+			Error: false,
+		},
+		{
+			Input: []string{"::/0"},
+			Output: []string{
+				"ip6.arpa",
+			},
+			Error: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		output, err := ReverseZones(testCase.Input)
+		if err != nil {
+			if !testCase.Error {
+				t.Errorf("ReverseZones(%#v) failed: %s", testCase.Input, err.Error())
+			}
+			continue
+		}
+		if !reflect.DeepEqual(testCase.Output, output) {
+			t.Errorf("ReverseZones(%#v) expected: %#v, got: %#v", testCase.Input, testCase.Output, output)
+		}
+	}
+}
@@ -1,7 +1,102 @@
 package cidrman
 
+import (
+	"fmt"
+	"net"
+)
+
+// MaxSubnets is the most subnets Subnets, SubnetsIPNet and SubnetsPrefix return, about a million, enough to
+// divide a /8 into /28s. Dividing a network into more, such as an IPv4 /0 into /32s or a /64 into /128s,
+// is rejected with ErrTooManyBlocks instead of running out of memory.
+const MaxSubnets = 1 << maxSubnetBits
+
+// maxSubnetBits is the most bits the prefix length of the subnets may be longer than the network's.
+const maxSubnetBits = 20
+
+// subnets4 computes all the IPv4 subnets of the specified prefix within the network addr/ones.
+func subnets4(addr uint32, ones, prefix uint, emit emit4) error {
+	if prefix < ones || prefix > widthUInt32 {
+		return &PrefixLengthError{Prefix: int(prefix), Network: fmt.Sprintf("%v/%d", uint32ToIPV4(network4(addr, ones)), ones), Min: int(ones), Max: widthUInt32}
+	}
+	if prefix-ones > maxSubnetBits {
+		return ErrTooManyBlocks
+	}
+
+	addr = network4(addr, ones)
+	count := uint64(1) << (prefix - ones)
+	for i := uint64(0); i < count; i++ {
+		if err := splitRange4(addr, prefix, addr, broadcast4(addr, prefix), 0, emit); err != nil {
+			return err
+		}
+		addr = broadcast4(addr, prefix) + 1
+	}
+
+	return nil
+}
+
+// subnets6 computes all the IPv6 subnets of the specified prefix within the network addr/ones.
+func subnets6(addr uint128, ones, prefix uint, emit emit6) error {
+	if prefix < ones || prefix > widthUInt128 {
+		return &PrefixLengthError{Prefix: int(prefix), Network: fmt.Sprintf("%v/%d", uint128ToIPV6(network6(addr, ones)), ones), Min: int(ones), Max: widthUInt128}
+	}
+	if prefix-ones > maxSubnetBits {
+		return ErrTooManyBlocks
+	}
+
+	addr = network6(addr, ones)
+	last := broadcast6(addr, ones)
+	for {
+		bc := broadcast6(addr, prefix)
+		if err := splitRange6(addr, prefix, addr, bc, 0, emit); err != nil {
+			return err
+		}
+		if bc == last {
+			break
+		}
+		addr = bc.addOne()
+	}
+
+	return nil
+}
+
+// SubnetsIPNet divides up an IP network into smaller subnets based on a specified CIDR prefix.
+// It returns ErrTooManyBlocks if there would be more than MaxSubnets subnets.
+func SubnetsIPNet(network *net.IPNet, prefix int) ([]*net.IPNet, error) {
+	if network == nil {
+		return nil, nil
+	}
+	ones, bits := network.Mask.Size()
+	if prefix < 0 {
+		return nil, &PrefixLengthError{Prefix: prefix, Network: network.String(), Min: ones, Max: bits}
+	}
+
+	var subnets []*net.IPNet
+	if ip4 := network.IP.To4(); ip4 != nil {
+		if err := subnets4(ipv4ToUInt32(ip4), uint(ones), uint(prefix), appendIPNet4(&subnets)); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := subnets6(ipv6ToUInt128(network.IP.To16()), uint(ones), uint(prefix), appendIPNet6(&subnets)); err != nil {
+			return nil, err
+		}
+	}
+
+	return subnets, nil
+}
+
 // Subnets divides up CIDR block into smaller subnets based on a specified CIDR prefix.
+// It returns every subnet, up to MaxSubnets, such as the 131072 /25s of a /8, and ErrTooManyBlocks
+// if there would be more, as the list would not fit in memory.
 func Subnets(cidr string, prefix int) ([]string, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, &ParseError{Kind: KindCIDR, Input: cidr, Index: -1}
+	}
+
+	subnets, err := SubnetsIPNet(network, prefix)
+	if err != nil {
+		return nil, err
+	}
 
-	return nil, nil
+	return ipNets(subnets).toCIDRs(), nil
 }
@@ -0,0 +1,166 @@
+// go test -v -run="TestSubnets"
+
+package cidrman
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestSubnets(t *testing.T) {
+	type TestCase struct {
+		Input  string
+		Prefix int
+		Output []string
+		Error  bool
+	}
+
+	testCases := []TestCase{
+		{
+			Input:  "",
+			Prefix: 24,
+			Output: nil,
+			Error:  true,
+		},
+		{
+			Input:  "192.0.2.0/24",
+			Prefix: 23,
+			Output: nil,
+			Error:  true,
+		},
+		{
+			Input:  "192.0.2.0/24",
+			Prefix: 33,
+			Output: nil,
+			Error:  true,
+		},
+		{
+			Input:  "192.0.2.0/24",
+			Prefix: -1,
+			Output: nil,
+			Error:  true,
+		},
+		{
+			Input:  "192.0.2.0/24",
+			Prefix: 24,
+			Output: []string{
+				"192.0.2.0/24",
+			},
+			Error: false,
+		},
+		{
+			Input:  "192.0.2.0/24",
+			Prefix: 26,
+			Output: []string{
+				"192.0.2.0/26",
+				"192.0.2.64/26",
+				"192.0.2.128/26",
+				"192.0.2.192/26",
+			},
+			Error: false,
+		},
+		{
+			Input:  "192.0.2.77/30",
+			Prefix: 32,
+			Output: []string{
+				"192.0.2.76/32",
+				"192.0.2.77/32",
+				"192.0.2.78/32",
+				"192.0.2.79/32",
+			},
+			Error: false,
+		},
+		{
+			Input:  "0.0.0.0/0",
+			Prefix: 2,
+			Output: []string{
+				"0.0.0.0/2",
+				"64.0.0.0/2",
+				"128.0.0.0/2",
+				"192.0.0.0/2",
+			},
+			Error: false,
+		},
+		{
+			Input:  "2001:db8::/32",
+			Prefix: 31,
+			Output: nil,
+			Error:  true,
+		},
+		{
+			Input:  "2001:db8::/32",
+			Prefix: 129,
+			Output: nil,
+			Error:  true,
+		},
+		{
+			Input:  "0.0.0.0/0",
+			Prefix: 32,
+			Output: nil,
+			Error:  true,
+		},
+		{
+			Input:  "2001:db8::/64",
+			Prefix: 128,
+			Output: nil,
+			Error:  true,
+		},
+		{
+			Input:  "2001:db8::/32",
+			Prefix: 34,
+			Output: []string{
+				"2001:db8::/34",
+				"2001:db8:4000::/34",
+				"2001:db8:8000::/34",
+				"2001:db8:c000::/34",
+			},
+			Error: false,
+		},
+		{
+			Input:  "ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffc/126",
+			Prefix: 127,
+			Output: []string{
+				"ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffc/127",
+				"ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe/127",
+			},
+			Error: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		output, err := Subnets(testCase.Input, testCase.Prefix)
+		if err != nil {
+			if !testCase.Error {
+				t.Errorf("Subnets(%#v, %d) failed: %s", testCase.Input, testCase.Prefix, err.Error())
+			}
+			continue
+		}
+		if testCase.Error {
+			t.Errorf("Subnets(%#v, %d) expected error, got: %#v", testCase.Input, testCase.Prefix, output)
+			continue
+		}
+		if !reflect.DeepEqual(testCase.Output, output) {
+			t.Errorf("Subnets(%#v, %d) expected: %#v, got: %#v", testCase.Input, testCase.Prefix, testCase.Output, output)
+		}
+	}
+}
+
+func TestSubnetsLimit(t *testing.T) {
+	if subnets, err := Subnets("10.0.0.0/8", 25); err != nil || len(subnets) != 1<<17 {
+		t.Errorf("Subnets(10.0.0.0/8, 25) expected %d subnets, got: %d, %v", 1<<17, len(subnets), err)
+	}
+
+	subnets, err := Subnets("10.0.0.0/8", 8+maxSubnetBits)
+	if err != nil || len(subnets) != MaxSubnets {
+		t.Errorf("Subnets(10.0.0.0/8, %d) expected %d subnets, got: %d, %v", 8+maxSubnetBits, MaxSubnets, len(subnets), err)
+	}
+
+	if _, err := Subnets("10.0.0.0/8", 8+maxSubnetBits+1); !errors.Is(err, ErrTooManyBlocks) {
+		t.Errorf("Subnets(10.0.0.0/8, %d) expected error: %v, got: %v", 8+maxSubnetBits+1, ErrTooManyBlocks, err)
+	}
+	if _, err := SubnetsPrefix(netip.MustParsePrefix("2001:db8::/64"), 128); !errors.Is(err, ErrTooManyBlocks) {
+		t.Errorf("SubnetsPrefix(2001:db8::/64, 128) expected error: %v, got: %v", ErrTooManyBlocks, err)
+	}
+}
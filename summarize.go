@@ -0,0 +1,261 @@
+package cidrman
+
+import (
+	"container/heap"
+	"math/big"
+	"math/bits"
+	"net"
+)
+
+// Summary is a CIDR block of a lossy summarization and the number of addresses
+// it covers that were not in the input.
+type Summary struct {
+	CIDR      string
+	Overshoot *big.Int
+}
+
+// summaryBlock is an aligned CIDR block in the summarization. IPv4 addresses are kept in the
+// low bits of the 128-bit integers. All counts are modulo 2^128, which is exact as long as the
+// true value fits, and the only count that does not is the full IPv6 address space.
+type summaryBlock struct {
+	first   uint128
+	prefix  uint
+	width   uint
+	covered uint128
+}
+
+// last returns the last address of the block.
+func (b summaryBlock) last() uint128 {
+	return b.first.or(maxUInt128.rsh(widthUInt128 - b.width + b.prefix))
+}
+
+// size returns the number of addresses in the block.
+func (b summaryBlock) size() uint128 {
+	return uint128{lo: 1}.lsh(b.width - b.prefix)
+}
+
+// supernet returns the smallest aligned CIDR block containing both blocks, of the same family.
+func supernet(a, b summaryBlock) summaryBlock {
+	x := a.first.xor(b.last())
+	common := uint(bits.LeadingZeros64(x.hi))
+	if common == 64 {
+		common += uint(bits.LeadingZeros64(x.lo))
+	}
+	prefix := common - (widthUInt128 - a.width)
+	if prefix > a.prefix {
+		prefix = a.prefix
+	}
+
+	first := a.first.and(maxUInt128.rsh(widthUInt128 - a.width + prefix).not())
+	return summaryBlock{first: first, prefix: prefix, width: a.width}
+}
+
+// summaryNode is a block in the linked list of blocks being summarized.
+type summaryNode struct {
+	block      summaryBlock
+	prev, next *summaryNode
+	removed    bool
+}
+
+// summaryMerge is a candidate replacement of the adjacent blocks left and right, and any
+// neighbouring blocks it contains, by their supernet. It is stale once either block is removed.
+// As long as both remain, merges elsewhere never change the supernet or the addresses it covers.
+type summaryMerge struct {
+	left, right *summaryNode
+	super       summaryBlock
+	extra       uint128
+}
+
+// summaryQueue is a priority queue of merges, least extra addresses first, then longest prefix,
+// then lowest address, which is the order the merges are made in.
+type summaryQueue []*summaryMerge
+
+func (q summaryQueue) Len() int {
+	return len(q)
+}
+
+func (q summaryQueue) Less(i, j int) bool {
+	if c := q[i].extra.cmp(q[j].extra); c != 0 {
+		return c < 0
+	}
+	if q[i].super.prefix != q[j].super.prefix {
+		return q[i].super.prefix > q[j].super.prefix
+	}
+	a, b := q[i].left.block, q[j].left.block
+	if a.width != b.width {
+		return a.width < b.width
+	}
+	return a.first.cmp(b.first) < 0
+}
+
+func (q summaryQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *summaryQueue) Push(x interface{}) {
+	*q = append(*q, x.(*summaryMerge))
+}
+
+func (q *summaryQueue) Pop() interface{} {
+	old := *q
+	m := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return m
+}
+
+// mergeRange returns the first and last of the contiguous blocks around left and right contained in the supernet.
+func mergeRange(left, right *summaryNode, super summaryBlock) (*summaryNode, *summaryNode) {
+	from, to := left, right
+	for from.prev != nil && from.prev.block.width == super.width && from.prev.block.first.cmp(super.first) >= 0 {
+		from = from.prev
+	}
+	for to.next != nil && to.next.block.width == super.width && to.next.block.last().cmp(super.last()) <= 0 {
+		to = to.next
+	}
+
+	return from, to
+}
+
+// pushMerge queues the merge of the node with the next node, if they are of the same family.
+func pushMerge(q *summaryQueue, left *summaryNode) {
+	right := left.next
+	if right == nil || left.block.width != right.block.width {
+		return
+	}
+
+	super := supernet(left.block, right.block)
+	from, to := mergeRange(left, right, super)
+	for n := from; ; n = n.next {
+		super.covered = super.covered.add(n.block.covered)
+		if n == to {
+			break
+		}
+	}
+
+	heap.Push(q, &summaryMerge{left: left, right: right, super: super, extra: super.size().sub(super.covered)})
+}
+
+// summarize2 reduces the blocks, sorted and of at most two families, to maxEntries blocks by repeatedly
+// replacing the adjacent pair whose supernet adds the fewest addresses not in the input.
+func summarize2(blocks []summaryBlock, maxEntries int) []summaryBlock {
+	if len(blocks) <= maxEntries {
+		return blocks
+	}
+
+	var head, tail *summaryNode
+	for _, block := range blocks {
+		n := &summaryNode{block: block, prev: tail}
+		if tail == nil {
+			head = n
+		} else {
+			tail.next = n
+		}
+		tail = n
+	}
+
+	q := make(summaryQueue, 0, len(blocks))
+	for n := head; n.next != nil; n = n.next {
+		pushMerge(&q, n)
+	}
+
+	count := len(blocks)
+	for count > maxEntries && q.Len() > 0 {
+		m := heap.Pop(&q).(*summaryMerge)
+		if m.left.removed || m.right.removed {
+			continue
+		}
+
+		// Replace the blocks contained in the supernet by a single block.
+		from, to := mergeRange(m.left, m.right, m.super)
+		merged := &summaryNode{block: m.super, prev: from.prev, next: to.next}
+		for n := from; ; n = n.next {
+			n.removed = true
+			count--
+			if n == to {
+				break
+			}
+		}
+		count++
+
+		if merged.prev == nil {
+			head = merged
+		} else {
+			merged.prev.next = merged
+		}
+		if merged.next != nil {
+			merged.next.prev = merged
+		}
+
+		if merged.prev != nil {
+			pushMerge(&q, merged.prev)
+		}
+		pushMerge(&q, merged)
+	}
+
+	summarized := make([]summaryBlock, 0, count)
+	for n := head; n != nil; n = n.next {
+		summarized = append(summarized, n.block)
+	}
+
+	return summarized
+}
+
+// Summarize accepts a list of CIDR blocks and returns at most maxEntries CIDR blocks covering all of them,
+// including as few addresses not in the input as possible. Each CIDR block is returned with its overshoot,
+// the number of addresses it covers that were not in the input. Blocks are first merged losslessly,
+// then adjacent blocks are greedily replaced by their supernet, least overshoot first.
+func Summarize(cidrs []string, maxEntries int) ([]Summary, error) {
+	if cidrs == nil {
+		return nil, nil
+	}
+
+	merged, err := MergeCIDRs(cidrs)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []summaryBlock
+	families := 0
+	for i, cidr := range merged {
+		_, network, _ := net.ParseCIDR(cidr)
+		prefix, _ := network.Mask.Size()
+
+		block := summaryBlock{prefix: uint(prefix)}
+		if ip4 := network.IP.To4(); ip4 != nil {
+			block.first = uint128{lo: uint64(ipv4ToUInt32(ip4))}
+			block.width = widthUInt32
+		} else {
+			block.first = ipv6ToUInt128(network.IP.To16())
+			block.width = widthUInt128
+		}
+		block.covered = block.size()
+		if i == 0 || blocks[i-1].width != block.width {
+			families++
+		}
+		blocks = append(blocks, block)
+	}
+	if maxEntries < families {
+		return nil, ErrTooFewEntries
+	}
+
+	blocks = summarize2(blocks, maxEntries)
+
+	summaries := make([]Summary, 0, len(blocks))
+	for _, block := range blocks {
+		var ip net.IP
+		if block.width == widthUInt32 {
+			ip = uint32ToIPV4(uint32(block.first.lo))
+		} else {
+			ip = uint128ToIPV6(block.first)
+		}
+
+		overshoot := block.size().sub(block.covered)
+		b := make([]byte, net.IPv6len)
+		overshoot.putBytes(b)
+
+		network := net.IPNet{IP: ip, Mask: net.CIDRMask(int(block.prefix), int(block.width))}
+		summaries = append(summaries, Summary{CIDR: network.String(), Overshoot: big.NewInt(0).SetBytes(b)})
+	}
+
+	return summaries, nil
+}
@@ -0,0 +1,134 @@
+// go test -v -run="TestSummarize"
+
+package cidrman
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	type TestCase struct {
+		Input      []string
+		MaxEntries int
+		Output     []string
+		Overshoot  []string
+		Error      bool
+	}
+
+	testCases := []TestCase{
+		{
+			Input:      nil,
+			MaxEntries: 1,
+			Output:     nil,
+			Overshoot:  nil,
+			Error:      false,
+		},
+		{
+			Input:      []string{"10.0.0.0/33"},
+			MaxEntries: 1,
+			Error:      true,
+		},
+		{
+			Input:      []string{"10.0.0.0/8", "2001:db8::/32"},
+			MaxEntries: 1,
+			Error:      true,
+		},
+		{
+			// Lossless when the entries allow.
+			Input:      []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.3.0/24"},
+			MaxEntries: 2,
+			Output:     []string{"10.0.0.0/23", "10.0.3.0/24"},
+			Overshoot:  []string{"0", "0"},
+		},
+		{
+			Input:      []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.3.0/24"},
+			MaxEntries: 1,
+			Output:     []string{"10.0.0.0/22"},
+			Overshoot:  []string{"256"},
+		},
+		{
+			// The pair adding the fewest addresses is merged first.
+			Input:      []string{"10.0.0.0/24", "10.0.2.0/24", "192.0.2.0/25", "192.0.2.192/26"},
+			MaxEntries: 3,
+			Output:     []string{"10.0.0.0/24", "10.0.2.0/24", "192.0.2.0/24"},
+			Overshoot:  []string{"0", "0", "64"},
+		},
+		{
+			Input:      []string{"10.0.0.0/24", "10.0.2.0/24", "192.0.2.0/25", "192.0.2.192/26"},
+			MaxEntries: 2,
+			Output:     []string{"10.0.0.0/22", "192.0.2.0/24"},
+			Overshoot:  []string{"512", "64"},
+		},
+		{
+			Input:      []string{"10.0.0.0/24", "10.0.2.0/24", "192.0.2.0/25", "192.0.2.192/26"},
+			MaxEntries: 1,
+			Output:     []string{"0.0.0.0/0"},
+			Overshoot:  []string{"4294966592"},
+		},
+		{
+			Input:      []string{"2001:db8::/33", "2001:db8:c000::/34", "192.0.2.1/32"},
+			MaxEntries: 2,
+			Output:     []string{"192.0.2.1/32", "2001:db8::/32"},
+			Overshoot:  []string{"0", "19807040628566084398385987584"},
+		},
+		{
+			Input:      []string{"::/1", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff/128"},
+			MaxEntries: 1,
+			Output:     []string{"::/0"},
+			Overshoot:  []string{"170141183460469231731687303715884105727"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		output, err := Summarize(testCase.Input, testCase.MaxEntries)
+		if err != nil {
+			if !testCase.Error {
+				t.Errorf("Summarize(%#v, %d) failed: %s", testCase.Input, testCase.MaxEntries, err.Error())
+			}
+			continue
+		}
+		if testCase.Error {
+			t.Errorf("Summarize(%#v, %d) expected error", testCase.Input, testCase.MaxEntries)
+			continue
+		}
+
+		var cidrs, overshoot []string
+		for _, summary := range output {
+			cidrs = append(cidrs, summary.CIDR)
+			overshoot = append(overshoot, summary.Overshoot.String())
+		}
+		if !reflect.DeepEqual(testCase.Output, cidrs) || !reflect.DeepEqual(testCase.Overshoot, overshoot) {
+			t.Errorf("Summarize(%#v, %d) expected: %#v %#v, got: %#v %#v", testCase.Input, testCase.MaxEntries, testCase.Output, testCase.Overshoot, cidrs, overshoot)
+		}
+	}
+
+	if _, err := Summarize([]string{"10.0.0.0/8", "2001:db8::/32"}, 1); !errors.Is(err, ErrTooFewEntries) {
+		t.Errorf("Summarize expected: %v, got: %v", ErrTooFewEntries, err)
+	}
+}
+
+// go test -run=NONE -bench="BenchmarkSummarize"
+
+// BenchmarkSummarize cuts a list the size of a cloud provider's published ranges,
+// 20000 pseudo-random /24s, down to 1000 entries.
+func BenchmarkSummarize(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	cidrs := make([]string, 20000)
+	for i := range cidrs {
+		ip := make(net.IP, net.IPv4len)
+		r.Read(ip)
+		cidrs[i] = (&net.IPNet{IP: ip.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Summarize(cidrs, 1000); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
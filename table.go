@@ -0,0 +1,286 @@
+package cidrman
+
+import (
+	"math/bits"
+	"net"
+	"net/netip"
+)
+
+// Address families indexing the roots of a PrefixTable.
+const (
+	family4 = iota
+	family6
+)
+
+// PrefixTable maps IPv4 and IPv6 prefixes to values and supports longest prefix match lookups.
+// It is a path-compressed binary trie per address family, keyed by the address as an unsigned
+// integer with IPv4 addresses left-aligned in the 128-bit key.
+// The zero value is an empty table.
+type PrefixTable[V any] struct {
+	roots [2]*prefixNode[V]
+	len   int
+}
+
+// prefixNode is a node in the trie. Nodes without a value only exist to branch.
+type prefixNode[V any] struct {
+	key   uint128
+	bits  uint
+	child [2]*prefixNode[V]
+	value V
+	set   bool
+}
+
+// prefixKey returns the family, key and prefix length of the prefix.
+func prefixKey(prefix netip.Prefix) (int, uint128, uint) {
+	prefix = prefix.Masked()
+	if prefix.Addr().Is4() {
+		return family4, uint128{hi: uint64(addr4ToUInt32(prefix.Addr())) << widthUInt32}, uint(prefix.Bits())
+	}
+	return family6, addr6ToUInt128(prefix.Addr()), uint(prefix.Bits())
+}
+
+// addrKey returns the family, key and address width of the address.
+func addrKey(addr netip.Addr) (int, uint128, uint) {
+	if addr.Is4() {
+		return family4, uint128{hi: uint64(addr4ToUInt32(addr)) << widthUInt32}, widthUInt32
+	}
+	return family6, addr6ToUInt128(addr), widthUInt128
+}
+
+// keyPrefix converts a family, key and prefix length back into a prefix.
+func keyPrefix(family int, key uint128, prefix uint) netip.Prefix {
+	if family == family4 {
+		return netip.PrefixFrom(uint32ToAddr4(uint32(key.hi>>widthUInt32)), int(prefix))
+	}
+	return netip.PrefixFrom(uint128ToAddr6(key), int(prefix))
+}
+
+// keyBit returns the bit of the key at the specified position, counted from the most significant bit.
+func keyBit(key uint128, bit uint) int {
+	if bit < 64 {
+		return int(key.hi>>(63-bit)) & 1
+	}
+	return int(key.lo>>(127-bit)) & 1
+}
+
+// commonBits returns the number of leading bits the keys have in common, up to max.
+func commonBits(a, b uint128, max uint) uint {
+	x := a.xor(b)
+	n := uint(bits.LeadingZeros64(x.hi))
+	if n == 64 {
+		n += uint(bits.LeadingZeros64(x.lo))
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+// contains reports whether the node's prefix contains the key.
+func (n *prefixNode[V]) contains(key uint128) bool {
+	return commonBits(n.key, key, n.bits) == n.bits
+}
+
+// Len returns the number of prefixes in the table.
+func (t *PrefixTable[V]) Len() int {
+	return t.len
+}
+
+// Insert adds the prefix to the table with the specified value, replacing any existing value.
+// Host bits set in the prefix are ignored.
+func (t *PrefixTable[V]) Insert(prefix netip.Prefix, value V) error {
+	if !prefix.IsValid() {
+		return &ParseError{Kind: KindPrefix, Input: prefix.String(), Index: -1}
+	}
+
+	family, key, ones := prefixKey(prefix)
+	key = key.and(netmask6(ones))
+	leaf := &prefixNode[V]{key: key, bits: ones, value: value, set: true}
+
+	slot := &t.roots[family]
+	for {
+		n := *slot
+		if n == nil {
+			*slot = leaf
+			t.len++
+			return nil
+		}
+
+		common := commonBits(n.key, key, minBits(n.bits, ones))
+		switch {
+		case common == n.bits && n.bits == ones:
+			// Exact match, replace the value.
+			if !n.set {
+				t.len++
+			}
+			n.value = value
+			n.set = true
+			return nil
+		case common == n.bits:
+			// The node contains the prefix, descend.
+			slot = &n.child[keyBit(key, n.bits)]
+		case common == ones:
+			// The prefix contains the node, insert above it.
+			leaf.child[keyBit(n.key, ones)] = n
+			*slot = leaf
+			t.len++
+			return nil
+		default:
+			// The prefix and the node diverge, insert a branch.
+			branch := &prefixNode[V]{key: key.and(netmask6(common)), bits: common}
+			branch.child[keyBit(n.key, common)] = n
+			branch.child[keyBit(key, common)] = leaf
+			*slot = branch
+			t.len++
+			return nil
+		}
+	}
+}
+
+// Delete removes the prefix from the table, reporting whether it was present.
+func (t *PrefixTable[V]) Delete(prefix netip.Prefix) bool {
+	if !prefix.IsValid() {
+		return false
+	}
+
+	family, key, ones := prefixKey(prefix)
+
+	var parentSlot **prefixNode[V]
+	slot := &t.roots[family]
+	for n := *slot; n != nil; n = *slot {
+		if n.bits > ones || !n.contains(key) {
+			return false
+		}
+		if n.bits < ones {
+			parentSlot = slot
+			slot = &n.child[keyBit(key, n.bits)]
+			continue
+		}
+		if !n.set {
+			return false
+		}
+
+		var zero V
+		n.value = zero
+		n.set = false
+		t.len--
+
+		// Remove the node unless it is still needed to branch.
+		switch {
+		case n.child[0] != nil && n.child[1] != nil:
+			return true
+		case n.child[0] != nil:
+			*slot = n.child[0]
+		case n.child[1] != nil:
+			*slot = n.child[1]
+		default:
+			*slot = nil
+		}
+
+		// A parent left branching to a single child is no longer needed either.
+		if parentSlot != nil {
+			parent := *parentSlot
+			if !parent.set {
+				if parent.child[0] == nil {
+					*parentSlot = parent.child[1]
+				} else if parent.child[1] == nil {
+					*parentSlot = parent.child[0]
+				}
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// Get returns the value stored for exactly the prefix.
+func (t *PrefixTable[V]) Get(prefix netip.Prefix) (V, bool) {
+	var zero V
+	if !prefix.IsValid() {
+		return zero, false
+	}
+
+	family, key, ones := prefixKey(prefix)
+	for n := t.roots[family]; n != nil; n = n.child[keyBit(key, n.bits)] {
+		if n.bits > ones || !n.contains(key) {
+			break
+		}
+		if n.bits == ones {
+			if n.set {
+				return n.value, true
+			}
+			break
+		}
+	}
+
+	return zero, false
+}
+
+// Lookup returns the longest prefix in the table containing the address, and its value.
+func (t *PrefixTable[V]) Lookup(addr netip.Addr) (netip.Prefix, V, bool) {
+	var zero V
+	if !addr.IsValid() {
+		return netip.Prefix{}, zero, false
+	}
+
+	family, key, width := addrKey(addr)
+	var best *prefixNode[V]
+	for n := t.roots[family]; n != nil; n = n.child[keyBit(key, n.bits)] {
+		if !n.contains(key) {
+			break
+		}
+		if n.set {
+			best = n
+		}
+		if n.bits == width {
+			break
+		}
+	}
+
+	if best == nil {
+		return netip.Prefix{}, zero, false
+	}
+	return keyPrefix(family, best.key, best.bits), best.value, true
+}
+
+// LookupIP returns the longest prefix in the table containing the IP address, and its value.
+func (t *PrefixTable[V]) LookupIP(ip net.IP) (netip.Prefix, V, bool) {
+	addr, ok := netip.AddrFromSlice(ip)
+	if ok && ip.To4() != nil {
+		addr = addr.Unmap()
+	}
+
+	return t.Lookup(addr)
+}
+
+// Walk calls fn for each prefix in the table and its value, IPv4 prefixes first,
+// in ascending address order with shorter prefixes before the longer prefixes they contain.
+// The walk stops if fn returns false.
+func (t *PrefixTable[V]) Walk(fn func(prefix netip.Prefix, value V) bool) {
+	for family, root := range t.roots {
+		if !walkNode(family, root, fn) {
+			return
+		}
+	}
+}
+
+// walkNode walks the subtrie rooted at n in order, returning false if the walk was stopped.
+func walkNode[V any](family int, n *prefixNode[V], fn func(netip.Prefix, V) bool) bool {
+	if n == nil {
+		return true
+	}
+	if n.set && !fn(keyPrefix(family, n.key, n.bits), n.value) {
+		return false
+	}
+
+	return walkNode(family, n.child[0], fn) && walkNode(family, n.child[1], fn)
+}
+
+// minBits returns the smaller of two prefix lengths.
+func minBits(a, b uint) uint {
+	if a < b {
+		return a
+	}
+	return b
+}
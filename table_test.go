@@ -0,0 +1,168 @@
+// go test -v -run="TestPrefixTable"
+
+package cidrman
+
+import (
+	"math/rand"
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestPrefixTable(t *testing.T) {
+	var table PrefixTable[string]
+
+	for _, cidr := range []string{
+		"10.0.0.0/8",
+		"10.1.0.0/16",
+		"10.1.2.0/24",
+		"10.1.2.3/32",
+		"10.128.0.0/9",
+		"0.0.0.0/0",
+		"2001:db8::/32",
+		"2001:db8:1::/48",
+		"2001:db8:1::1/128",
+	} {
+		if err := table.Insert(netip.MustParsePrefix(cidr), cidr); err != nil {
+			t.Fatalf("Insert(%s) failed: %s", cidr, err.Error())
+		}
+	}
+	if err := table.Insert(netip.Prefix{}, ""); err == nil {
+		t.Errorf("Insert with an invalid prefix expected error")
+	}
+	if table.Len() != 9 {
+		t.Errorf("Len expected: 9, got: %d", table.Len())
+	}
+
+	type TestCase struct {
+		Input  string
+		Output string
+		Found  bool
+	}
+
+	testCases := []TestCase{
+		{Input: "10.1.2.3", Output: "10.1.2.3/32", Found: true},
+		{Input: "10.1.2.4", Output: "10.1.2.0/24", Found: true},
+		{Input: "10.1.3.4", Output: "10.1.0.0/16", Found: true},
+		{Input: "10.2.3.4", Output: "10.0.0.0/8", Found: true},
+		{Input: "10.200.3.4", Output: "10.128.0.0/9", Found: true},
+		{Input: "192.0.2.1", Output: "0.0.0.0/0", Found: true},
+		{Input: "2001:db8:1::1", Output: "2001:db8:1::1/128", Found: true},
+		{Input: "2001:db8:1::2", Output: "2001:db8:1::/48", Found: true},
+		{Input: "2001:db8:2::1", Output: "2001:db8::/32", Found: true},
+		{Input: "fd00::1", Output: "", Found: false},
+	}
+
+	for _, testCase := range testCases {
+		prefix, value, found := table.Lookup(netip.MustParseAddr(testCase.Input))
+		if found != testCase.Found || value != testCase.Output {
+			t.Errorf("Lookup(%s) expected: %#v %v, got: %#v %v", testCase.Input, testCase.Output, testCase.Found, value, found)
+		}
+		if found && prefix.String() != testCase.Output {
+			t.Errorf("Lookup(%s) expected prefix: %s, got: %s", testCase.Input, testCase.Output, prefix)
+		}
+
+		_, value, found = table.LookupIP(net.ParseIP(testCase.Input))
+		if found != testCase.Found || value != testCase.Output {
+			t.Errorf("LookupIP(%s) expected: %#v %v, got: %#v %v", testCase.Input, testCase.Output, testCase.Found, value, found)
+		}
+	}
+
+	if value, ok := table.Get(netip.MustParsePrefix("10.1.0.0/16")); !ok || value != "10.1.0.0/16" {
+		t.Errorf("Get(10.1.0.0/16) expected: %#v, got: %#v %v", "10.1.0.0/16", value, ok)
+	}
+	if _, ok := table.Get(netip.MustParsePrefix("10.1.0.0/17")); ok {
+		t.Errorf("Get(10.1.0.0/17) expected not found")
+	}
+	if _, ok := table.Get(netip.MustParsePrefix("2001:db8::/31")); ok {
+		t.Errorf("Get(2001:db8::/31) expected not found")
+	}
+
+	var walked []string
+	table.Walk(func(prefix netip.Prefix, value string) bool {
+		walked = append(walked, value)
+		return true
+	})
+	expected := []string{
+		"0.0.0.0/0",
+		"10.0.0.0/8",
+		"10.1.0.0/16",
+		"10.1.2.0/24",
+		"10.1.2.3/32",
+		"10.128.0.0/9",
+		"2001:db8::/32",
+		"2001:db8:1::/48",
+		"2001:db8:1::1/128",
+	}
+	if !reflect.DeepEqual(expected, walked) {
+		t.Errorf("Walk expected: %#v, got: %#v", expected, walked)
+	}
+
+	for _, cidr := range []string{"10.1.2.0/24", "0.0.0.0/0", "2001:db8:1::/48"} {
+		if !table.Delete(netip.MustParsePrefix(cidr)) {
+			t.Errorf("Delete(%s) expected true", cidr)
+		}
+	}
+	if table.Delete(netip.MustParsePrefix("10.1.2.0/24")) {
+		t.Errorf("Delete(10.1.2.0/24) twice expected false")
+	}
+	if table.Len() != 6 {
+		t.Errorf("Len expected: 6, got: %d", table.Len())
+	}
+	if _, value, _ := table.Lookup(netip.MustParseAddr("10.1.2.4")); value != "10.1.0.0/16" {
+		t.Errorf("Lookup(10.1.2.4) after Delete expected: %#v, got: %#v", "10.1.0.0/16", value)
+	}
+	if _, _, found := table.Lookup(netip.MustParseAddr("192.0.2.1")); found {
+		t.Errorf("Lookup(192.0.2.1) after Delete expected not found")
+	}
+	if _, value, _ := table.Lookup(netip.MustParseAddr("2001:db8:1::2")); value != "2001:db8::/32" {
+		t.Errorf("Lookup(2001:db8:1::2) after Delete expected: %#v, got: %#v", "2001:db8::/32", value)
+	}
+}
+
+// TestPrefixTableRandom compares lookups against a linear scan over random prefixes.
+func TestPrefixTableRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	nets := randomIPNets(2000, net.IPv4len)
+
+	var table PrefixTable[int]
+	prefixes := make(map[netip.Prefix]int)
+	for i, n := range nets {
+		ones, _ := n.Mask.Size()
+		addr, _ := netip.AddrFromSlice(n.IP)
+		prefix := netip.PrefixFrom(addr, ones)
+		table.Insert(prefix, i)
+		prefixes[prefix] = i
+	}
+
+	// Delete every other prefix to exercise node removal.
+	for _, n := range nets[:len(nets)/2] {
+		ones, _ := n.Mask.Size()
+		addr, _ := netip.AddrFromSlice(n.IP)
+		prefix := netip.PrefixFrom(addr, ones)
+		table.Delete(prefix)
+		delete(prefixes, prefix)
+	}
+	if table.Len() != len(prefixes) {
+		t.Fatalf("Len expected: %d, got: %d", len(prefixes), table.Len())
+	}
+
+	for i := 0; i < 10000; i++ {
+		var b [4]byte
+		r.Read(b[:])
+		addr := netip.AddrFrom4(b)
+
+		var best netip.Prefix
+		for prefix := range prefixes {
+			if prefix.Contains(addr) && (!best.IsValid() || prefix.Bits() > best.Bits()) {
+				best = prefix
+			}
+		}
+
+		prefix, value, found := table.Lookup(addr)
+		if found != best.IsValid() || (found && (prefix != best || value != prefixes[best])) {
+			t.Fatalf("Lookup(%s) expected: %v, got: %v %v", addr, best, prefix, found)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package cidrman
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// uint128 is an unsigned 128-bit integer made up of two 64-bit halves.
+// It is a value type, so unlike big.Int the arithmetic does not allocate.
+type uint128 struct {
+	hi uint64
+	lo uint64
+}
+
+// uint128FromBytes converts a 16 byte big endian slice to an unsigned 128-bit integer.
+func uint128FromBytes(b []byte) uint128 {
+	return uint128{hi: binary.BigEndian.Uint64(b[:8]), lo: binary.BigEndian.Uint64(b[8:])}
+}
+
+// putBytes writes the unsigned 128-bit integer to a 16 byte big endian slice.
+func (u uint128) putBytes(b []byte) {
+	binary.BigEndian.PutUint64(b[:8], u.hi)
+	binary.BigEndian.PutUint64(b[8:], u.lo)
+}
+
+// isZero reports whether u is zero.
+func (u uint128) isZero() bool {
+	return u.hi == 0 && u.lo == 0
+}
+
+// cmp compares u and v and returns -1, 0 or +1.
+func (u uint128) cmp(v uint128) int {
+	if u.hi < v.hi {
+		return -1
+	} else if u.hi > v.hi {
+		return 1
+	}
+
+	if u.lo < v.lo {
+		return -1
+	} else if u.lo > v.lo {
+		return 1
+	}
+
+	return 0
+}
+
+// add returns u+v, wrapping around on overflow.
+func (u uint128) add(v uint128) uint128 {
+	lo, carry := bits.Add64(u.lo, v.lo, 0)
+	hi, _ := bits.Add64(u.hi, v.hi, carry)
+	return uint128{hi: hi, lo: lo}
+}
+
+// sub returns u-v, wrapping around on underflow.
+func (u uint128) sub(v uint128) uint128 {
+	lo, borrow := bits.Sub64(u.lo, v.lo, 0)
+	hi, _ := bits.Sub64(u.hi, v.hi, borrow)
+	return uint128{hi: hi, lo: lo}
+}
+
+// addOne returns u+1, wrapping around on overflow.
+func (u uint128) addOne() uint128 {
+	return u.add(uint128{lo: 1})
+}
+
+// subOne returns u-1, wrapping around on underflow.
+func (u uint128) subOne() uint128 {
+	return u.sub(uint128{lo: 1})
+}
+
+// and returns the bitwise and of u and v.
+func (u uint128) and(v uint128) uint128 {
+	return uint128{hi: u.hi & v.hi, lo: u.lo & v.lo}
+}
+
+// or returns the bitwise or of u and v.
+func (u uint128) or(v uint128) uint128 {
+	return uint128{hi: u.hi | v.hi, lo: u.lo | v.lo}
+}
+
+// xor returns the bitwise exclusive or of u and v.
+func (u uint128) xor(v uint128) uint128 {
+	return uint128{hi: u.hi ^ v.hi, lo: u.lo ^ v.lo}
+}
+
+// not returns the bitwise complement of u.
+func (u uint128) not() uint128 {
+	return uint128{hi: ^u.hi, lo: ^u.lo}
+}
+
+// lsh returns u shifted left by n bits.
+func (u uint128) lsh(n uint) uint128 {
+	if n >= 64 {
+		return uint128{hi: u.lo << (n - 64), lo: 0}
+	}
+	return uint128{hi: u.hi<<n | u.lo>>(64-n), lo: u.lo << n}
+}
+
+// rsh returns u shifted right by n bits.
+func (u uint128) rsh(n uint) uint128 {
+	if n >= 64 {
+		return uint128{hi: 0, lo: u.hi >> (n - 64)}
+	}
+	return uint128{hi: u.hi >> n, lo: u.lo>>n | u.hi<<(64-n)}
+}
+
+// setBit returns u with the specified bit, counted from the least significant bit, set to 1.
+func (u uint128) setBit(bit uint) uint128 {
+	return u.or(uint128{lo: 1}.lsh(bit))
+}
+
+// onesCount returns the number of one bits in u.
+func (u uint128) onesCount() int {
+	return bits.OnesCount64(u.hi) + bits.OnesCount64(u.lo)
+}
+
+// trailingZeros returns the number of trailing zero bits in u, 128 for zero.
+func (u uint128) trailingZeros() int {
+	if u.lo != 0 {
+		return bits.TrailingZeros64(u.lo)
+	}
+	return 64 + bits.TrailingZeros64(u.hi)
+}
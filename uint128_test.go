@@ -0,0 +1,57 @@
+// go test -v -run="TestUInt128"
+
+package cidrman
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUInt128(t *testing.T) {
+	type TestCase struct {
+		Name   string
+		Output uint128
+		Expect uint128
+	}
+
+	one := uint128{lo: 1}
+	max64 := uint128{lo: math.MaxUint64}
+
+	testCases := []TestCase{
+		{Name: "add carry", Output: max64.add(one), Expect: uint128{hi: 1}},
+		{Name: "add overflow", Output: maxUInt128.addOne(), Expect: uint128{}},
+		{Name: "sub borrow", Output: uint128{hi: 1}.subOne(), Expect: max64},
+		{Name: "sub underflow", Output: uint128{}.subOne(), Expect: maxUInt128},
+		{Name: "lsh 0", Output: one.lsh(0), Expect: one},
+		{Name: "lsh 63", Output: one.lsh(63), Expect: uint128{lo: 1 << 63}},
+		{Name: "lsh 64", Output: one.lsh(64), Expect: uint128{hi: 1}},
+		{Name: "lsh 127", Output: one.lsh(127), Expect: uint128{hi: 1 << 63}},
+		{Name: "lsh 128", Output: one.lsh(128), Expect: uint128{}},
+		{Name: "rsh 0", Output: maxUInt128.rsh(0), Expect: maxUInt128},
+		{Name: "rsh 1", Output: maxUInt128.rsh(1), Expect: uint128{hi: math.MaxUint64 >> 1, lo: math.MaxUint64}},
+		{Name: "rsh 64", Output: maxUInt128.rsh(64), Expect: max64},
+		{Name: "rsh 127", Output: maxUInt128.rsh(127), Expect: one},
+		{Name: "rsh 128", Output: maxUInt128.rsh(128), Expect: uint128{}},
+		{Name: "and", Output: maxUInt128.and(max64), Expect: max64},
+		{Name: "or", Output: uint128{hi: 1}.or(one), Expect: uint128{hi: 1, lo: 1}},
+		{Name: "xor", Output: maxUInt128.xor(max64), Expect: uint128{hi: math.MaxUint64}},
+		{Name: "not", Output: max64.not(), Expect: uint128{hi: math.MaxUint64}},
+		{Name: "setBit 64", Output: uint128{}.setBit(64), Expect: uint128{hi: 1}},
+	}
+
+	for _, testCase := range testCases {
+		if testCase.Output != testCase.Expect {
+			t.Errorf("%s expected: %#v, got: %#v", testCase.Name, testCase.Expect, testCase.Output)
+		}
+	}
+
+	if (uint128{hi: 1}).cmp(max64) != 1 || max64.cmp(uint128{hi: 1}) != -1 || one.cmp(one) != 0 {
+		t.Errorf("cmp failed")
+	}
+	if maxUInt128.onesCount() != 128 || (uint128{hi: 3, lo: 1}).onesCount() != 3 {
+		t.Errorf("onesCount failed")
+	}
+	if (uint128{}).trailingZeros() != 128 || (uint128{hi: 2}).trailingZeros() != 65 || one.trailingZeros() != 0 {
+		t.Errorf("trailingZeros failed")
+	}
+}